@@ -0,0 +1,80 @@
+package player
+
+import (
+	"math/rand"
+
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/strategy"
+)
+
+// RandomizedPlayer is an AIPlayer whose choices depend on a random source.
+// Random sources are not thread-safe, so anything that plays on several
+// threads at once (such as the simmer) should make a copy of the player
+// for every thread.
+type RandomizedPlayer interface {
+	AIPlayer
+	// WithRandSource returns a copy of the player that uses the given
+	// random source.
+	WithRandSource(*rand.Rand) AIPlayer
+}
+
+// TopNPlayer assigns equities like a RawEquityPlayer, but instead of always
+// picking the best play, it picks one of its top N plays at random. This
+// is meant to look more like a human (club) player than a perfect static
+// evaluator does.
+type TopNPlayer struct {
+	RawEquityPlayer
+	n          int
+	randSource *rand.Rand
+	top        []*move.Move
+}
+
+// NewTopNPlayer creates a player that picks uniformly among its n best
+// plays by equity.
+func NewTopNPlayer(s strategy.Strategizer, n int, randSource *rand.Rand) *TopNPlayer {
+	if n < 1 {
+		n = 1
+	}
+	return &TopNPlayer{
+		RawEquityPlayer: RawEquityPlayer{strategy: s},
+		n:               n,
+		randSource:      randSource,
+		top:             make([]*move.Move, 0, n),
+	}
+}
+
+// N returns the number of plays this player picks from.
+func (p *TopNPlayer) N() int {
+	return p.n
+}
+
+// WithRandSource returns a copy of the player with its own random source.
+func (p *TopNPlayer) WithRandSource(r *rand.Rand) AIPlayer {
+	return NewTopNPlayer(p.strategy, p.n, r)
+}
+
+// BestPlay picks one of the top N plays at random. It does not sort the
+// plays, as we only need the first few of them.
+func (p *TopNPlayer) BestPlay(moves []*move.Move) *move.Move {
+	top := p.top[:0]
+	for _, m := range moves {
+		if len(top) == p.n && m.Equity() <= top[len(top)-1].Equity() {
+			continue
+		}
+		if len(top) < p.n {
+			top = append(top, nil)
+		}
+		// Insert the move, keeping the plays sorted by equity. Plays with
+		// the same equity stay in their original order.
+		i := len(top) - 1
+		for ; i > 0 && top[i-1].Equity() < m.Equity(); i-- {
+			top[i] = top[i-1]
+		}
+		top[i] = m
+	}
+	p.top = top
+	if len(top) == 0 {
+		return nil
+	}
+	return top[p.randSource.Intn(len(top))]
+}
@@ -1,20 +1,103 @@
 package player
 
 import (
+	"math"
+
+	"github.com/domino14/macondo/alphabet"
 	"github.com/domino14/macondo/game"
 	"github.com/domino14/macondo/move"
 	"github.com/domino14/macondo/movegen"
 )
 
 // GenBestStaticTurn is a useful utility function for sims and autoplaying.
-func GenBestStaticTurn(game *game.Game, movegen movegen.MoveGenerator,
+// With a streaming move generator, only the plays the player picks from
+// are kept, instead of every play; if it can also shadow the anchors, the
+// ones that can't have any of those plays are skipped.
+func GenBestStaticTurn(game *game.Game, gen movegen.MoveGenerator,
 	aiplayer AIPlayer, playerIdx int) *move.Move {
 
 	opp := (playerIdx + 1) % game.NumPlayers()
-
 	// Add an exchange only if there are 7 or more tiles in the bag.
-	movegen.GenAll(game.RackFor(playerIdx), game.Bag().TilesRemaining() >= 7)
-	aiplayer.AssignEquity(movegen.Plays(), game.Board(), game.Bag(),
-		game.RackFor(opp))
-	return aiplayer.BestPlay(movegen.Plays())
+	addExchange := game.Bag().TilesRemaining() >= 7
+
+	sgen, ok := gen.(movegen.StreamingMoveGenerator)
+	if !ok {
+		gen.GenAll(game.RackFor(playerIdx), addExchange)
+		aiplayer.AssignEquity(gen.Plays(), game.Board(), game.Bag(),
+			game.RackFor(opp))
+		return aiplayer.BestPlay(gen.Plays())
+	}
+
+	one := make([]*move.Move, 1)
+	equity := func(m *move.Move) float64 {
+		one[0] = m
+		aiplayer.AssignEquity(one, game.Board(), game.Bag(), game.RackFor(opp))
+		return m.Equity()
+	}
+	recorder := movegen.NewTopPlaysRecorder(playsToPickFrom(aiplayer), equity)
+	// On an empty board there is only one anchor anyway, and the
+	// placement adjustment isn't part of the bound.
+	if shgen, ok := gen.(movegen.ShadowMoveGenerator); ok && !game.Board().IsEmpty() {
+		bound := equityBound(game, aiplayer, playerIdx)
+		shgen.GenAllWithShadow(game.RackFor(playerIdx), addExchange, recorder, bound)
+	} else {
+		sgen.GenAllWithRecorder(game.RackFor(playerIdx), addExchange, recorder.Record)
+	}
+	return aiplayer.BestPlay(recorder.Plays())
+}
+
+// equityBound bounds the equity of the player's plays. Once the board isn't
+// empty, the equity of a play is its score, plus something that only
+// depends on its leave, so the most any leave of the rack adds to the
+// score, for every number of tiles played, makes the bound.
+func equityBound(game *game.Game, aiplayer AIPlayer, playerIdx int) movegen.EquityBound {
+	rack := game.RackFor(playerIdx)
+	opp := game.RackFor((playerIdx + 1) % game.NumPlayers())
+	numTiles := int(rack.NumTiles())
+	extra := make([]float64, numTiles+1)
+	for i := range extra {
+		extra[i] = math.Inf(-1)
+	}
+
+	one := make([]*move.Move, 1)
+	letters := make([]int, len(rack.LetArr))
+	copy(letters, rack.LetArr)
+	var leave alphabet.MachineWord
+	// Try every leave once, taking the tiles off letter by letter.
+	var tryLeaves func(ml int)
+	tryLeaves = func(ml int) {
+		if ml == len(letters) {
+			tilesPlayed := numTiles - len(leave)
+			if tilesPlayed == 0 {
+				return
+			}
+			one[0] = move.NewScoringMove(0, nil, append(alphabet.MachineWord(nil), leave...),
+				false, tilesPlayed, rack.Alphabet(), 0, 0, "")
+			aiplayer.AssignEquity(one, game.Board(), game.Bag(), opp)
+			if e := one[0].Equity(); e > extra[tilesPlayed] {
+				extra[tilesPlayed] = e
+			}
+			return
+		}
+		n := len(leave)
+		for i := 0; i <= letters[ml]; i++ {
+			tryLeaves(ml + 1)
+			leave = append(leave, alphabet.MachineLetter(ml))
+		}
+		leave = leave[:n]
+	}
+	tryLeaves(0)
+
+	return func(score, tilesPlayed int) float64 {
+		return float64(score) + extra[tilesPlayed]
+	}
+}
+
+// playsToPickFrom returns how many of the top plays by equity the player
+// picks its play from.
+func playsToPickFrom(aiplayer AIPlayer) int {
+	if p, ok := aiplayer.(interface{ N() int }); ok {
+		return p.N()
+	}
+	return 1
 }
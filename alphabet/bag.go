@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
 
 	"github.com/rs/zerolog/log"
 )
@@ -73,6 +74,15 @@ func (b *Bag) Shuffle() {
 	})
 }
 
+// Reseed puts the tiles in the bag in a canonical order, reseeds the bag's
+// random source, and shuffles it. Whatever is drawn afterwards then only
+// depends on the tiles in the bag and the seed.
+func (b *Bag) Reseed(seed int64) {
+	sort.Slice(b.tiles, func(i, j int) bool { return b.tiles[i] < b.tiles[j] })
+	b.randSource.Seed(seed)
+	b.Shuffle()
+}
+
 // Exchange exchanges the junk in your rack with new tiles.
 func (b *Bag) Exchange(letters []MachineLetter) ([]MachineLetter, error) {
 	newTiles, err := b.Draw(len(letters))
@@ -138,7 +148,10 @@ func (b *Bag) rebuildTileSlice(numTilesInBag int) error {
 	}
 	b.tiles = make([]MachineLetter, numTilesInBag)
 	idx := 0
-	for let, ct := range b.tileMap {
+	// Go over the letters in order, rather than over the map, so that the
+	// shuffle below only depends on the random source.
+	for let := MachineLetter(0); let <= BlankMachineLetter; let++ {
+		ct := b.tileMap[let]
 		for j := uint8(0); j < ct; j++ {
 			b.tiles[idx] = let
 			idx++
@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -131,6 +132,33 @@ func (ld *LetterDistribution) Alphabet() *Alphabet {
 	return ld.alph
 }
 
+// TileLetters returns the letters of the distribution, without the blank,
+// in the order they are listed in its file.
+func (ld *LetterDistribution) TileLetters() []rune {
+	letters := []rune{}
+	for letter := range ld.SortOrder {
+		if letter != BlankToken {
+			letters = append(letters, letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		return ld.SortOrder[letters[i]] < ld.SortOrder[letters[j]]
+	})
+	return letters
+}
+
+// DistributionNameForLexicon returns the name of the letter distribution
+// that the lexicon is played with.
+func DistributionNameForLexicon(lexiconName string) string {
+	switch {
+	case strings.HasPrefix(lexiconName, "OSPS"):
+		return "polish"
+	case strings.HasPrefix(lexiconName, "FISE"):
+		return "spanish"
+	}
+	return "english"
+}
+
 func (ld *LetterDistribution) NumTotalTiles() int {
 	return ld.numLetters
 }
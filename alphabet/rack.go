@@ -154,6 +154,22 @@ func (r *Rack) TilesOn() MachineWord {
 	return MachineWord(letters)
 }
 
+// AppendTilesOn appends the tiles on the rack to the word, in the same
+// order as TilesOn, and returns it. It doesn't allocate if the word has
+// room for them.
+func (r *Rack) AppendTilesOn(w MachineWord) MachineWord {
+	numPossibleLetters := r.alphabet.NumLetters()
+	for i := MachineLetter(0); i < MachineLetter(numPossibleLetters); i++ {
+		for j := 0; j < r.LetArr[i]; j++ {
+			w = append(w, i)
+		}
+	}
+	for j := 0; j < r.LetArr[BlankMachineLetter]; j++ {
+		w = append(w, BlankMachineLetter)
+	}
+	return w
+}
+
 // ScoreOn returns the total score of the tiles on this rack.
 func (r *Rack) ScoreOn(ld *LetterDistribution) int {
 	score := 0
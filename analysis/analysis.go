@@ -0,0 +1,460 @@
+// Package analysis analyzes a whole game, turn by turn. For every turn, it
+// finds the best play, either by simming the top candidates or by solving
+// the endgame, and works out how much equity the play that was actually
+// made gave up.
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/endgame/alphabeta"
+	"github.com/domino14/macondo/game"
+	"github.com/domino14/macondo/gcgio"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/montecarlo"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/runner"
+)
+
+const (
+	// DefaultCandidates is the number of top static plays that are simmed.
+	DefaultCandidates = 10
+	DefaultPlies      = 2
+	DefaultIterations = 400
+	// DefaultEndgamePlies is the depth of the endgame solver, for turns
+	// where the bag is empty.
+	DefaultEndgamePlies = 4
+	// DefaultMistakeThreshold is how much equity a play must lose to count
+	// as a mistake. Sims are noisy, so this shouldn't be too small.
+	DefaultMistakeThreshold = 4.0
+)
+
+// Method is how a turn was analyzed.
+type Method int
+
+const (
+	// MethodStatic compares the plays by static equity only.
+	MethodStatic Method = iota
+	MethodSim
+	MethodEndgame
+)
+
+func (m Method) String() string {
+	switch m {
+	case MethodSim:
+		return "sim"
+	case MethodEndgame:
+		return "endgame"
+	}
+	return "static"
+}
+
+// TurnAnalysis is the analysis of a single turn.
+type TurnAnalysis struct {
+	// EventIdx is the index of the turn's event in the game history.
+	EventIdx int
+	Nickname string
+	Rack     string
+	Method   Method
+
+	Played string
+	// Phony is set if the play was challenged off the board. It is then
+	// valued as a pass.
+	Phony       bool
+	PlayedValue float64
+	Best        string
+	BestValue   float64
+	// Loss is how much equity (or spread, in the endgame) the play gave
+	// up compared to the best play. It is never negative.
+	Loss float64
+}
+
+// Note returns a short annotation of the turn, for a GCG note.
+func (t *TurnAnalysis) Note() string {
+	played := t.Played
+	if t.Phony {
+		played += " (phony)"
+	}
+	if t.Loss == 0 {
+		return fmt.Sprintf("[%v] %v is the best play (%.1f)", t.Method, played, t.PlayedValue)
+	}
+	return fmt.Sprintf("[%v] best is %v (%.1f); %v (%.1f) loses %.1f",
+		t.Method, t.Best, t.BestValue, played, t.PlayedValue, t.Loss)
+}
+
+// PlayerSummary sums up the mistakes of one player.
+type PlayerSummary struct {
+	Nickname   string
+	Turns      int
+	Mistakes   int
+	EquityLost float64
+	// Worst is the turn where the player lost the most equity, if any.
+	Worst *TurnAnalysis
+}
+
+// GameAnalysis is the analysis of a whole game.
+type GameAnalysis struct {
+	// History is a copy of the game history, with a note on every analyzed
+	// turn.
+	History *pb.GameHistory
+	Turns   []*TurnAnalysis
+	Players []*PlayerSummary
+}
+
+// GCG returns the annotated game as a GCG. It has the lexicon and the
+// variant, so that it can be replayed.
+func (ga *GameAnalysis) GCG() (string, error) {
+	return gcgio.GameHistoryToGCG(ga.History, true)
+}
+
+// Summary returns a table of every player's mistakes.
+func (ga *GameAnalysis) Summary() string {
+	var ss strings.Builder
+	fmt.Fprintf(&ss, "%20v %6v %9v %11v  %v\n", "Player", "Turns", "Mistakes", "Equity lost", "Worst turn")
+	for _, p := range ga.Players {
+		worst := ""
+		if p.Worst != nil {
+			worst = fmt.Sprintf("#%d %v (-%.1f)", p.Worst.EventIdx+1, p.Worst.Played, p.Worst.Loss)
+		}
+		fmt.Fprintf(&ss, "%20v %6d %9d %11.1f  %v\n", p.Nickname, p.Turns, p.Mistakes,
+			p.EquityLost, worst)
+	}
+	return ss.String()
+}
+
+// Analyzer analyzes games. Create it with NewAnalyzer.
+type Analyzer struct {
+	cfg          *config.Config
+	candidates   int
+	plies        int
+	iterations   int
+	threads      int
+	endgamePlies int
+	threshold    float64
+}
+
+// NewAnalyzer creates an analyzer with the default settings.
+func NewAnalyzer(cfg *config.Config) *Analyzer {
+	return &Analyzer{
+		cfg:          cfg,
+		candidates:   DefaultCandidates,
+		plies:        DefaultPlies,
+		iterations:   DefaultIterations,
+		endgamePlies: DefaultEndgamePlies,
+		threshold:    DefaultMistakeThreshold,
+	}
+}
+
+// SetCandidates sets how many of the top static plays are simmed. The
+// play that was actually made is always simmed as well.
+func (a *Analyzer) SetCandidates(n int) {
+	a.candidates = n
+}
+
+func (a *Analyzer) SetPlies(n int) {
+	a.plies = n
+}
+
+// SetIterations sets how many iterations every turn is simmed for. With 0,
+// plays are only compared by static equity.
+func (a *Analyzer) SetIterations(n int) {
+	a.iterations = n
+}
+
+// SetThreads sets the number of sim threads. 0 uses the simmer's default.
+func (a *Analyzer) SetThreads(n int) {
+	a.threads = n
+}
+
+// SetEndgamePlies sets the depth of the endgame solver, for turns where the
+// bag is empty. With 0, these turns are simmed like any other.
+func (a *Analyzer) SetEndgamePlies(n int) {
+	a.endgamePlies = n
+}
+
+// SetMistakeThreshold sets how much equity a play must lose to count as a
+// mistake.
+func (a *Analyzer) SetMistakeThreshold(t float64) {
+	a.threshold = t
+}
+
+// Analyze analyzes every turn of the game where we know the rack of the
+// player on turn. The history is not modified.
+func (a *Analyzer) Analyze(ctx context.Context, history *pb.GameHistory) (*GameAnalysis, error) {
+	// Replaying the game writes to its history, so work on a copy.
+	hist := proto.Clone(history).(*pb.GameHistory)
+	lexicon := hist.Lexicon
+	if lexicon == "" {
+		lexicon = a.cfg.DefaultLexicon
+	}
+	boardLayout, ldName := game.HistoryToVariant(hist)
+	rules, err := runner.NewAIGameRules(a.cfg, boardLayout, hist.Variant, lexicon, ldName)
+	if err != nil {
+		return nil, err
+	}
+	g, err := game.NewFromHistory(hist, rules, 0)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := runner.NewAIGameRunnerFromGame(g, a.cfg)
+	if err != nil {
+		return nil, err
+	}
+	// Like in the shell; the game record might have phonies in it, and
+	// we don't want to stop replaying it there.
+	gr.SetChallengeRule(pb.ChallengeRule_DOUBLE)
+
+	ga := &GameAnalysis{History: proto.Clone(history).(*pb.GameHistory)}
+	summaries := map[string]*PlayerSummary{}
+	for _, p := range hist.Players {
+		summaries[p.Nickname] = &PlayerSummary{Nickname: p.Nickname}
+		ga.Players = append(ga.Players, summaries[p.Nickname])
+	}
+
+	for idx, evt := range hist.Events {
+		switch evt.Type {
+		case pb.GameEvent_TILE_PLACEMENT_MOVE, pb.GameEvent_EXCHANGE, pb.GameEvent_PASS:
+		default:
+			continue
+		}
+		if evt.Rack == "" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ta, err := a.analyzeTurn(ctx, gr, idx)
+		if err != nil {
+			return nil, fmt.Errorf("turn %v: %v", idx+1, err)
+		}
+		if ta == nil {
+			continue
+		}
+		log.Info().Int("turn", idx+1).Str("player", ta.Nickname).Str("note", ta.Note()).
+			Msg("analyzed-turn")
+		ga.Turns = append(ga.Turns, ta)
+
+		annotated := ga.History.Events[idx]
+		if annotated.Note != "" {
+			annotated.Note += "\n"
+		}
+		annotated.Note += ta.Note()
+
+		sum, ok := summaries[ta.Nickname]
+		if !ok {
+			continue
+		}
+		sum.Turns++
+		sum.EquityLost += ta.Loss
+		if ta.Loss >= a.threshold {
+			sum.Mistakes++
+		}
+		if ta.Loss > 0 && (sum.Worst == nil || ta.Loss > sum.Worst.Loss) {
+			sum.Worst = ta
+		}
+	}
+	return ga, nil
+}
+
+// analyzeTurn analyzes the turn at the given event index. It returns nil
+// if there is nothing to analyze, for example once the game is over.
+func (a *Analyzer) analyzeTurn(ctx context.Context, gr *runner.AIGameRunner,
+	idx int) (*TurnAnalysis, error) {
+
+	hist := gr.History()
+	evt := hist.Events[idx]
+	err := gr.PlayToTurn(idx)
+	if err != nil {
+		return nil, err
+	}
+	if gr.Playing() != pb.PlayState_PLAYING {
+		return nil, nil
+	}
+	pidx := -1
+	for i, p := range hist.Players {
+		if p.Nickname == evt.Nickname {
+			pidx = i
+		}
+	}
+	if pidx == -1 {
+		return nil, fmt.Errorf("player not found: %v", evt.Nickname)
+	}
+	alph := gr.Alphabet()
+	gr.SetPlayerOnTurn(pidx)
+	err = gr.SetRackFor(pidx, alphabet.RackFromString(evt.Rack, alph))
+	if err != nil {
+		return nil, err
+	}
+
+	ta := &TurnAnalysis{EventIdx: idx, Nickname: evt.Nickname, Rack: evt.Rack}
+	actual := game.MoveFromEvent(evt, alph, gr.Board())
+	if actual == nil {
+		return nil, nil
+	}
+	ta.Played = actual.ShortDescription()
+	if idx+1 < len(hist.Events) && hist.Events[idx+1].Type == pb.GameEvent_PHONY_TILES_RETURNED {
+		// The play came off the board, so all the player did was pass.
+		ta.Phony = true
+		actual = move.NewPassMove(gr.RackFor(pidx).TilesOn(), alph)
+	}
+
+	if gr.Bag().TilesRemaining() == 0 && a.endgamePlies > 0 {
+		err = a.solveTurn(ctx, gr, pidx, actual, ta)
+	} else {
+		err = a.simTurn(ctx, gr, pidx, actual, ta)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ta.Loss = math.Max(0, ta.BestValue-ta.PlayedValue)
+	if ta.Loss == 0 {
+		ta.BestValue = ta.PlayedValue
+		ta.Best = ta.Played
+	}
+	return ta, nil
+}
+
+// simTurn compares the plays by static equity, and then sims the top
+// candidates along with the actual play, if we are simming at all.
+func (a *Analyzer) simTurn(ctx context.Context, gr *runner.AIGameRunner, pidx int,
+	actual *move.Move, ta *TurnAnalysis) error {
+
+	plays := gr.GenerateMoves(math.MaxInt32)
+	if len(plays) == 0 {
+		return fmt.Errorf("no plays generated for rack %v", ta.Rack)
+	}
+	actualIdx := -1
+	for i, p := range plays {
+		if sameMove(p, actual) {
+			actualIdx = i
+			break
+		}
+	}
+	if actualIdx == -1 {
+		// The movegen won't find phonies, for example.
+		gr.AssignEquity([]*move.Move{actual}, gr.RackFor((pidx+1)%gr.NumPlayers()))
+	} else {
+		actual = plays[actualIdx]
+	}
+
+	if a.iterations <= 0 {
+		ta.Method = MethodStatic
+		ta.Best = plays[0].ShortDescription()
+		ta.BestValue = plays[0].Equity()
+		ta.PlayedValue = actual.Equity()
+		return nil
+	}
+
+	ncand := a.candidates
+	if ncand > len(plays) {
+		ncand = len(plays)
+	}
+	candidates := append([]*move.Move(nil), plays[:ncand]...)
+	if actualIdx == -1 || actualIdx >= ncand {
+		candidates = append(candidates, actual)
+	}
+	simmer := &montecarlo.Simmer{}
+	simmer.Init(&gr.Game, gr.AIPlayer())
+	if a.threads > 0 {
+		simmer.SetThreads(a.threads)
+	}
+	simmer.SetIterationLimit(a.iterations)
+	err := simmer.PrepareSim(a.plies, candidates)
+	if err != nil {
+		return err
+	}
+	err = simmer.Simulate(ctx)
+	if err != nil {
+		return err
+	}
+	res := simmer.Results()
+	ta.Method = MethodSim
+	ta.Best = res.Plays[0].Description
+	ta.BestValue = res.Plays[0].Equity.Mean
+	for _, p := range res.Plays {
+		if p.Description == actual.ShortDescription() {
+			ta.PlayedValue = p.Equity.Mean
+		}
+	}
+	return nil
+}
+
+// solveTurn solves the endgame, for the best play and for the actual play.
+// The values are the spread the player gains until the end of the game
+// (or as far as the solver looks).
+func (a *Analyzer) solveTurn(ctx context.Context, gr *runner.AIGameRunner,
+	pidx int, actual *move.Move, ta *TurnAnalysis) error {
+
+	gr.SetStateStackLength(a.endgamePlies + 1)
+	gr.SetBackupMode(game.SimulationMode)
+	defer gr.SetBackupMode(game.InteractiveGameplayMode)
+
+	solver := &alphabeta.Solver{}
+	err := solver.Init(gr.MoveGenerator(), &gr.Game)
+	if err != nil {
+		return err
+	}
+	bestV, seq, err := solver.Solve(ctx, a.endgamePlies)
+	if err != nil {
+		return err
+	}
+	ta.Method = MethodEndgame
+	ta.Best = seq[0].ShortDescription()
+	ta.BestValue = float64(bestV)
+	if sameMove(seq[0], actual) {
+		ta.PlayedValue = ta.BestValue
+		return nil
+	}
+
+	// Play the actual move, and see how well the opponent can do after it.
+	// The opponent looks one ply less deep, so both plays are searched
+	// to the same depth.
+	spreadBefore := gr.SpreadFor(pidx)
+	err = gr.PlayMove(actual, false, 0)
+	if err != nil {
+		return err
+	}
+	defer gr.UnplayLastMove()
+	ta.PlayedValue = float64(gr.SpreadFor(pidx) - spreadBefore)
+	if gr.Playing() == pb.PlayState_GAME_OVER {
+		return nil
+	}
+	oppPlies := a.endgamePlies - 1
+	if oppPlies < 1 {
+		oppPlies = 1
+	}
+	oppV, _, err := solver.Solve(ctx, oppPlies)
+	if err != nil {
+		return err
+	}
+	ta.PlayedValue -= float64(oppV)
+	return nil
+}
+
+// sameMove returns whether the two moves are the same play. Exchanges are
+// the same if they exchange the same tiles, in any order.
+func sameMove(m1, m2 *move.Move) bool {
+	if m1.Action() != m2.Action() {
+		return false
+	}
+	if m1.Action() != move.MoveTypeExchange {
+		return m1.ShortDescription() == m2.ShortDescription()
+	}
+	t1 := append(alphabet.MachineWord(nil), m1.Tiles()...)
+	t2 := append(alphabet.MachineWord(nil), m2.Tiles()...)
+	if len(t1) != len(t2) {
+		return false
+	}
+	sort.Slice(t1, func(i, j int) bool { return t1[i] < t1[j] })
+	sort.Slice(t2, func(i, j int) bool { return t2[i] < t2[j] })
+	return t1.String() == t2.String()
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/gaddagmaker"
+	"github.com/domino14/macondo/gcgio"
+)
+
+var DefaultConfig = config.DefaultConfig()
+
+func TestMain(m *testing.M) {
+	for _, lex := range []string{"NWL18"} {
+		gdgPath := filepath.Join(DefaultConfig.LexiconPath, "gaddag", lex+".gaddag")
+		if _, err := os.Stat(gdgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateGaddag(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.Rename("out.gaddag", gdgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestAnalyzeStatic(t *testing.T) {
+	is := is.New(t)
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/doug_v_emely.gcg")
+	is.NoErr(err)
+
+	a := NewAnalyzer(&DefaultConfig)
+	a.SetIterations(0)
+	a.SetEndgamePlies(0)
+	ga, err := a.Analyze(context.Background(), history)
+	is.NoErr(err)
+	is.True(len(ga.Turns) > 0)
+	is.Equal(len(ga.Players), 2)
+
+	turns := 0
+	for _, p := range ga.Players {
+		turns += p.Turns
+	}
+	is.Equal(turns, len(ga.Turns))
+	for _, ta := range ga.Turns {
+		is.Equal(ta.Method, MethodStatic)
+		is.True(ta.Loss >= 0)
+		is.True(ta.BestValue >= ta.PlayedValue)
+		is.True(strings.Contains(ga.History.Events[ta.EventIdx].Note, ta.Note()))
+		// The original history is left alone.
+		is.True(!strings.Contains(history.Events[ta.EventIdx].Note, ta.Note()))
+	}
+
+	gcg, err := ga.GCG()
+	is.NoErr(err)
+	is.True(strings.Contains(gcg, "#note [static]"))
+	// The annotated game can be read back in.
+	reparsed, err := gcgio.ParseGCGFromReader(&DefaultConfig, strings.NewReader(gcg))
+	is.NoErr(err)
+	is.Equal(len(reparsed.Events), len(history.Events))
+}
+
+func TestAnalyzeGCGKeepsLexiconAndVariant(t *testing.T) {
+	is := is.New(t)
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/super.gcg")
+	is.NoErr(err)
+	// Not the default lexicon, so that it has to be written out.
+	history.Lexicon = "CSW19"
+
+	a := NewAnalyzer(&DefaultConfig)
+	a.SetIterations(0)
+	a.SetEndgamePlies(0)
+	ga, err := a.Analyze(context.Background(), history)
+	is.NoErr(err)
+	gcg, err := ga.GCG()
+	is.NoErr(err)
+	reparsed, err := gcgio.ParseGCGFromReader(&DefaultConfig, strings.NewReader(gcg))
+	is.NoErr(err)
+	is.Equal(reparsed.Lexicon, "CSW19")
+	is.Equal(reparsed.Variant, history.Variant)
+	is.Equal(len(reparsed.Events), len(history.Events))
+}
+
+func TestAnalyzeEndgame(t *testing.T) {
+	is := is.New(t)
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/doug_v_emely.gcg")
+	is.NoErr(err)
+
+	a := NewAnalyzer(&DefaultConfig)
+	a.SetIterations(0)
+	a.SetEndgamePlies(2)
+	ga, err := a.Analyze(context.Background(), history)
+	is.NoErr(err)
+	endgameTurns := 0
+	for _, ta := range ga.Turns {
+		if ta.Method == MethodEndgame {
+			endgameTurns++
+		}
+		is.True(ta.Loss >= 0)
+	}
+	is.True(endgameTurns > 0)
+}
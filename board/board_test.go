@@ -6,6 +6,7 @@ import (
 	"github.com/matryer/is"
 
 	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/config"
 	"github.com/domino14/macondo/move"
 )
 
@@ -148,3 +149,32 @@ func TestFormedWordsBlank(t *testing.T) {
 	}
 	is.Equal(uvWords, []string{"TAEL", "TA", "AN", "RESPONDED", "LO"})
 }
+
+func TestSuperCrosswordGameBoard(t *testing.T) {
+	is := is.New(t)
+	cfg := config.DefaultConfig()
+	ld, err := alphabet.NamedLetterDistribution(&cfg, "englishsuper")
+	is.NoErr(err)
+	is.Equal(ld.NumTotalTiles(), 200)
+
+	b := MakeBoard(SuperCrosswordGameBoard)
+	is.Equal(b.Dim(), 21)
+	for r := 0; r < b.Dim(); r++ {
+		for c := 0; c < b.Dim(); c++ {
+			is.Equal(b.GetBonus(r, c), b.GetBonus(c, r))
+			is.Equal(b.GetBonus(r, c), b.GetBonus(b.Dim()-1-r, c))
+		}
+	}
+	is.Equal(b.GetBonus(0, 0), Bonus4WS)
+	is.Equal(b.GetBonus(2, 5), Bonus4LS)
+
+	alph := ld.Alphabet()
+	word, err := alphabet.ToMachineWord("QUA", alph)
+	is.NoErr(err)
+	// A1 QUA: (10 + 1 + 1) * 4
+	is.Equal(b.ScoreWord(word, 0, 0, 3, VerticalDirection, ld), 48)
+	word, err = alphabet.ToMachineWord("ZA", alph)
+	is.NoErr(err)
+	// 3F ZA: 10 * 4 + 1
+	is.Equal(b.ScoreWord(word, 2, 5, 2, VerticalDirection, ld), 41)
+}
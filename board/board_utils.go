@@ -26,7 +26,7 @@ func (g *GameBoard) ToDisplayText(alph *alphabet.Alphabet) string {
 		row = row + fmt.Sprintf("%c", 'A'+i) + " "
 	}
 	str = str + row + "\n"
-	str = str + "   " + strings.Repeat("-", 30) + "\n"
+	str = str + "   " + strings.Repeat("-", 2*n) + "\n"
 	for i := 0; i < n; i++ {
 		row := fmt.Sprintf("%2d|", i+1)
 		for j := 0; j < n; j++ {
@@ -49,7 +49,7 @@ func (g *GameBoard) setFromPlaintext(qText string,
 	// (Another alternative later is to implement GCG)
 	playedTiles := []alphabet.MachineLetter(nil)
 	result := boardPlaintextRegex.FindAllStringSubmatch(qText, -1)
-	if len(result) != 15 {
+	if len(result) != g.Dim() {
 		panic("Wrongly implemented")
 	}
 	g.tilesPlayed = 0
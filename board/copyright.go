@@ -4,6 +4,9 @@ var (
 	// CrosswordGameBoard is a board for a fun Crossword Game, featuring lots
 	// of wingos and blonks.
 	CrosswordGameBoard []string
+	// SuperCrosswordGameBoard is a 21x21 board for a bigger Crossword Game,
+	// with quadruple word and letter scores, played with 200 tiles.
+	SuperCrosswordGameBoard []string
 )
 
 func init() {
@@ -24,4 +27,27 @@ func init() {
 		` -   "   "   - `,
 		`=  '   =   '  =`,
 	}
+	SuperCrosswordGameBoard = []string{
+		`~  '   =  '  =   '  ~`,
+		` -  "   -   -   "  - `,
+		`  -  ^   - -   ^  -  `,
+		`'  =  '   =   '  =  '`,
+		` "  -   "   "   -  " `,
+		`  ^  -   ' '   -  ^  `,
+		`   '  -   '   -  '   `,
+		`=      -     -      =`,
+		` -  "   "   "   "  - `,
+		`  -  '   ' '   '  -  `,
+		`'  =  '   -   '  =  '`,
+		`  -  '   ' '   '  -  `,
+		` -  "   "   "   "  - `,
+		`=      -     -      =`,
+		`   '  -   '   -  '   `,
+		`  ^  -   ' '   -  ^  `,
+		` "  -   "   "   -  " `,
+		`'  =  '   =   '  =  '`,
+		`  -  ^   - -   ^  -  `,
+		` -  "   -   -   "  - `,
+		`~  '   =  '  =   '  ~`,
+	}
 }
@@ -25,6 +25,10 @@ const (
 	Bonus2LS BonusSquare = '\''
 	// Bonus2WS is a double word score
 	Bonus2WS BonusSquare = '-'
+	// Bonus4WS is a quadruple word score
+	Bonus4WS BonusSquare = '~'
+	// Bonus4LS is a quadruple letter score
+	Bonus4LS BonusSquare = '^'
 )
 
 // A Square is a single square in a game board. It contains the bonus markings,
@@ -121,6 +125,10 @@ func (b BonusSquare) displayString() string {
 	}
 	switch b {
 
+	case Bonus4WS:
+		return fmt.Sprintf("\033[33m%s\033[0m", string(b))
+	case Bonus4LS:
+		return fmt.Sprintf("\033[32m%s\033[0m", string(b))
 	case Bonus3WS:
 		return fmt.Sprintf("\033[31m%s\033[0m", string(b))
 	case Bonus2WS:
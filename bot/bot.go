@@ -1,16 +1,20 @@
 package bot
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"runtime"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/nats-io/nats.go"
 	"github.com/rs/zerolog/log"
 
 	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/endgame"
+	"github.com/domino14/macondo/endgame/alphabeta"
 	"github.com/domino14/macondo/game"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
 	"github.com/domino14/macondo/move"
@@ -22,11 +26,20 @@ func debugWriteln(msg string) {
 	io.WriteString(os.Stderr, "\n")
 }
 
+const (
+	// EndgamePlies is how deep the bot searches endgames.
+	EndgamePlies = 4
+	// endgameTimeLimit bounds the time the alphabeta solver takes for a
+	// move; the deepest search that finished is used.
+	endgameTimeLimit = 10 * time.Second
+)
+
 type Bot struct {
 	config  *config.Config
 	options *runner.GameOptions
 
-	game *runner.AIGameRunner
+	game             *runner.AIGameRunner
+	newEndgameSolver endgame.NewSolverFunc
 }
 
 func NewBot(config *config.Config, options *runner.GameOptions) *Bot {
@@ -34,9 +47,46 @@ func NewBot(config *config.Config, options *runner.GameOptions) *Bot {
 	bot.config = config
 	bot.options = options
 	bot.game = nil
+	bot.newEndgameSolver = alphabeta.NewSolver
 	return bot
 }
 
+// SetEndgameSolver sets the function that makes the solver the bot plays
+// endgames with, once the bag is empty.
+func (bot *Bot) SetEndgameSolver(f endgame.NewSolverFunc) {
+	bot.newEndgameSolver = f
+}
+
+// solveEndgame returns the first move of the best sequence the endgame
+// solver finds, or nil if the solver fails.
+func (bot *Bot) solveEndgame(g *runner.AIGameRunner) *move.Move {
+	// The solver needs to back up every ply it plays; go back to the
+	// game's own backup mode when it's done.
+	prevMode := g.BackupMode()
+	g.SetStateStackLength(EndgamePlies)
+	g.SetBackupMode(game.SimulationMode)
+	defer g.SetBackupMode(prevMode)
+
+	solver := bot.newEndgameSolver()
+	err := solver.Init(g.MoveGenerator(), &g.Game)
+	if err != nil {
+		log.Error().Err(err).Msg("init-endgame-solver")
+		return nil
+	}
+	if ab, ok := solver.(*alphabeta.Solver); ok {
+		ab.SetTimeLimit(endgameTimeLimit)
+	}
+	_, seq, err := solver.Solve(context.Background(), EndgamePlies)
+	if err != nil || len(seq) == 0 {
+		log.Error().Err(err).Msg("solving-endgame")
+		return nil
+	}
+	stats := solver.Stats()
+	log.Info().Int("plies", stats.Depth).Int("nodes", stats.Nodes).
+		Dur("elapsed", stats.Elapsed).Msg("solved-endgame")
+	return seq[0]
+}
+
 func (bot *Bot) newGame() error {
 	players := []*pb.PlayerInfo{
 		{Nickname: "self", RealName: "Macondo Bot"},
@@ -69,7 +119,7 @@ func (bot *Bot) Deserialize(data []byte) (*game.Game, error) {
 	}
 	history := req.GameHistory
 	boardLayout, ldName := game.HistoryToVariant(history)
-	rules, err := runner.NewAIGameRules(bot.config, boardLayout, history.Lexicon, ldName)
+	rules, err := runner.NewAIGameRules(bot.config, boardLayout, history.Variant, history.Lexicon, ldName)
 	if err != nil {
 		return nil, err
 	}
@@ -111,8 +161,13 @@ func (bot *Bot) handle(data []byte) *pb.BotResponse {
 	if !valid {
 		m, _ = g.NewChallengeMove(g.PlayerOnTurn())
 	} else if g.IsPlaying() {
-		moves := bot.game.GenerateMoves(1)
-		m = moves[0]
+		if g.Bag().TilesRemaining() == 0 {
+			m = bot.solveEndgame(g)
+		}
+		if m == nil {
+			moves := bot.game.GenerateMoves(1)
+			m = moves[0]
+		}
 	} else {
 		m, _ = g.NewPassMove(g.PlayerOnTurn())
 	}
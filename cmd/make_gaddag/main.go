@@ -3,20 +3,42 @@ package main
 import (
 	"flag"
 
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/config"
 	"github.com/domino14/macondo/gaddagmaker"
 )
 
 func main() {
-	structtype := flag.String("type", "gaddag", "gaddag or dawg")
+	structtype := flag.String("type", "gaddag", "gaddag, dawg, alphagrams (a dawg of alphagrams, for WordSmog), kwg, or klv (from a file of leave,value lines)")
 	minimize := flag.Bool("minimize", true, "minimize the gaddag/dawg")
 	reverse := flag.Bool("reverse", false, "reverse the dawg (ignored for gaddags)")
 	filename := flag.String("filename", "", "filename of the word list")
+	aligned := flag.Bool("mmap", false, "save the gaddag in the aligned format, which can be memory-mapped")
+	letterDistribution := flag.String("letterdistribution", "english", "the letter distribution that numbers the tiles of a kwg or klv")
 
 	flag.Parse()
-	if *structtype == "gaddag" {
+	if *structtype == "gaddag" && *aligned {
+		gd := gaddagmaker.GenerateGaddag(*filename, *minimize, false)
+		if gd != nil {
+			gd.SaveAligned("out.gaddag")
+		}
+	} else if *structtype == "gaddag" {
 		gaddagmaker.GenerateGaddag(*filename, *minimize, true)
 	} else if *structtype == "dawg" {
 		gaddagmaker.GenerateDawg(*filename, *minimize, true, *reverse)
+	} else if *structtype == "alphagrams" {
+		gaddagmaker.GenerateAlphagramDawg(*filename, *minimize, true)
+	} else if *structtype == "kwg" || *structtype == "klv" {
+		cfg := config.DefaultConfig()
+		ld, err := alphabet.NamedLetterDistribution(&cfg, *letterDistribution)
+		if err != nil {
+			panic(err)
+		}
+		if *structtype == "kwg" {
+			gaddagmaker.GenerateKWG(*filename, *minimize, true, ld.TileLetters())
+		} else {
+			gaddagmaker.GenerateKLV(*filename, true, ld.TileLetters())
+		}
 	} else {
 		panic("Unsupported data structure " + *structtype)
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/domino14/macondo/board"
 	"github.com/domino14/macondo/gaddag"
 	"github.com/domino14/macondo/gaddagmaker"
+	"github.com/domino14/macondo/lexicon"
 	"github.com/domino14/macondo/move"
 )
 
@@ -21,9 +22,10 @@ const (
 )
 
 // Public cross_set.Generator Interface
-// There are two concrete implementations below,
+// There are three concrete implementations below,
 // - CrossScoreOnlyGenerator{Dist}
 // - GaddagCrossSetGenerator{Dist, Gaddag}
+// - LexiconCrossSetGenerator{Dist, Lexicon}
 
 type Generator interface {
 	Generate(b *Board, row int, col int, dir board.BoardDirection)
@@ -335,3 +337,50 @@ func GenCrossSet(b *Board, row int, col int, dir board.BoardDirection,
 		}
 	}
 }
+
+// ----------------------------------------------------------------------
+// LexiconCrossSetGenerator generates cross sets by trying every letter
+// against a lexicon. It is slower than the gaddag generator, but it works
+// for any lexicon, such as the anagram lexicon of WordSmog.
+
+type LexiconCrossSetGenerator struct {
+	Dist    *alphabet.LetterDistribution
+	Lexicon lexicon.Lexicon
+}
+
+func (g LexiconCrossSetGenerator) Generate(b *Board, row int, col int, dir board.BoardDirection) {
+	if row < 0 || row >= b.Dim() || col < 0 || col >= b.Dim() {
+		return
+	}
+	genCrossScore(b, row, col, dir, g.Dist)
+	if !b.GetSquare(row, col).IsEmpty() {
+		b.GetSquare(row, col).SetCrossSet(CrossSet(0), dir)
+		return
+	}
+	if b.LeftAndRightEmpty(row, col) {
+		b.GetSquare(row, col).SetCrossSet(board.TrivialCrossSet, dir)
+		return
+	}
+	leftCol := b.WordEdge(row, col-1, Left)
+	rightCol := b.WordEdge(row, col+1, Right)
+	word := make(alphabet.MachineWord, rightCol-leftCol+1)
+	for c := leftCol; c <= rightCol; c++ {
+		word[c-leftCol] = b.GetLetter(row, c).Unblank()
+	}
+	crossSet := CrossSet(0)
+	for ml := alphabet.MachineLetter(0); ml < alphabet.MachineLetter(g.Lexicon.GetAlphabet().NumLetters()); ml++ {
+		word[col-leftCol] = ml
+		if g.Lexicon.HasWord(word) {
+			crossSet.Set(ml)
+		}
+	}
+	b.GetSquare(row, col).SetCrossSet(crossSet, dir)
+}
+
+func (g LexiconCrossSetGenerator) GenerateAll(b *Board) {
+	generateAll(g, b)
+}
+
+func (g LexiconCrossSetGenerator) UpdateForMove(b *Board, m *move.Move) {
+	updateForMove(g, b, m)
+}
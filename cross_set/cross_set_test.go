@@ -663,6 +663,49 @@ func TestCompareGenAll(t *testing.T) {
 	}
 }
 
+func TestLexiconCrossSets(t *testing.T) {
+	// With the same lexicon, trying every letter makes the same cross-sets
+	// as the gaddag does.
+	gd, err := GaddagFromLexicon("America")
+	if err != nil {
+		t.Error(err)
+	}
+	dist, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	if err != nil {
+		t.Error(err)
+	}
+	gen1 := GaddagCrossSetGenerator{Dist: dist, Gaddag: gd}
+	gen2 := LexiconCrossSetGenerator{Dist: dist, Lexicon: gaddag.Lexicon{GenericDawg: gd}}
+	alph := dist.Alphabet()
+
+	var testCases = []updateCrossesForMoveTestCase{
+		{VsMatt, move.NewScoringMoveSimple(38, "K9", "TAEL", "ABD", alph), "TAEL"},
+		{VsMatt2, move.NewScoringMoveSimple(77, "O8", "TENsILE", "", alph), "TENsILE"},
+		{VsOxy, move.NewScoringMoveSimple(1780, "A1", "OX.P...B..AZ..E", "", alph),
+			"OXYPHENBUTAZONE"},
+		{VsJeremy, move.NewScoringMoveSimple(14, "1G", "S.oWED", "D?", alph), "SNoWED"},
+		{VsEd, move.NewScoringMoveSimple(11, "15F", "F..ER", "", alph), "FOYER"},
+	}
+	for _, tc := range testCases {
+		b1 := board.MakeBoard(board.CrosswordGameBoard)
+		b1.SetToGame(alph, tc.testGame)
+		gen1.GenerateAll(b1)
+		b1.UpdateAllAnchors()
+
+		b2 := board.MakeBoard(board.CrosswordGameBoard)
+		b2.SetToGame(alph, tc.testGame)
+		gen2.GenerateAll(b2)
+		b2.UpdateAllAnchors()
+		assert.True(t, b1.Equals(b2))
+
+		b1.PlayMove(tc.m, dist)
+		gen1.UpdateForMove(b1, tc.m)
+		b2.PlayMove(tc.m, dist)
+		gen2.UpdateForMove(b2, tc.m)
+		assert.True(t, b1.Equals(b2))
+	}
+}
+
 // Benchmarks
 
 func BenchmarkGenAnchorsAndCrossSets(b *testing.B) {
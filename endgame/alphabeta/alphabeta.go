@@ -3,10 +3,13 @@
 package alphabeta
 
 import (
+	"context"
 	"errors"
 	"sort"
+	"time"
 
 	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/endgame"
 	"github.com/domino14/macondo/game"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
 	"github.com/domino14/macondo/move"
@@ -53,6 +56,9 @@ const (
 	// blocked. We just make it 1 because our 2-ply evaluation function
 	// is reasonably accurate.
 	FutureAdjustment = float32(1)
+	// stopCheckInterval is how many nodes we visit between checks of
+	// whether the search should stop.
+	stopCheckInterval = 1000
 )
 
 // Solver implements the minimax + alphabeta algorithm.
@@ -68,6 +74,9 @@ type Solver struct {
 	iterativeDeepeningOn bool
 	disablePruning       bool
 	rootNode             *GameNode
+	// pvNode is the last node of the principal variation of the deepest
+	// search that finished.
+	pvNode *GameNode
 	// Some helpful variables to avoid big allocations
 	// stm: side-to-move  ots: other side
 	stmPlayed []bool
@@ -77,6 +86,40 @@ type Solver struct {
 	otsBlockingRects []rect
 	stmRectIndex     int
 	otsRectIndex     int
+
+	// The transposition table remembers positions that were already
+	// searched, as the same position can often be reached with
+	// different move orders.
+	ttableMB int
+	ttable   *transpositionTable
+	zobrist  *zobrist
+
+	threads     int
+	workers     []*Solver
+	rankedMoves int
+	rootResults []rootResult
+	rankings    []RankedMove
+
+	negascout    bool
+	moveOrdering bool
+	killers      [][numKillers]uint64
+	history      map[uint64]int
+
+	timeLimit        time.Duration
+	progressCallback func(Progress)
+	ctx              context.Context
+	stopped          bool
+	nodesSinceCheck  int
+	stats            endgame.Stats
+}
+
+// Progress is reported every time the search finishes a depth.
+type Progress struct {
+	Depth    int
+	Nodes    int
+	Value    float32
+	Sequence []*move.Move
+	Elapsed  time.Duration
 }
 
 // max returns the larger of x or y.
@@ -94,6 +137,12 @@ func min(x, y float32) float32 {
 	return y
 }
 
+// NewSolver returns a new alphabeta solver, for callers that take an
+// endgame.NewSolverFunc.
+func NewSolver() endgame.Solver {
+	return &Solver{}
+}
+
 // Init initializes the solver
 func (s *Solver) Init(movegen movegen.MoveGenerator, game *game.Game) error {
 	s.movegen = movegen
@@ -105,6 +154,15 @@ func (s *Solver) Init(movegen movegen.MoveGenerator, game *game.Game) error {
 	s.otsPlayed = make([]bool, alphabet.MaxAlphabetSize+1)
 	s.stmBlockingRects = make([]rect, 20)
 	s.otsBlockingRects = make([]rect, 25)
+
+	s.ttableMB = DefaultTranspositionTableMB
+	s.ttable = nil
+	s.zobrist = nil
+	s.threads = 1
+	s.workers = nil
+	s.negascout = true
+	s.moveOrdering = true
+	s.ctx = context.Background()
 	return nil
 }
 
@@ -294,14 +352,19 @@ func (s *Solver) generateSTMPlays(parent *GameNode) []*move.Move {
 	return sideToMovePlays
 }
 
-func (s *Solver) childGenerator(node *GameNode, maximizingPlayer bool) func() (
-	*GameNode, bool) {
+func (s *Solver) childGenerator(node *GameNode, depth int, maximizingPlayer bool,
+	ttMove uint64) func() (*GameNode, bool) {
 
 	// log.Debug().Msgf("Trying to generate children for node %v", node)
 	var plays []*move.Move
 	if node.children == nil {
 		plays = s.generateSTMPlays(node)
 		node.generatedPlays = plays
+		// Right above the leaves, the plays are best left in the order
+		// of their valuation; see frontierCutoff.
+		if depth > 1 || !s.moveOrdering {
+			s.orderPlays(plays, ttMove)
+		}
 	} else {
 		sort.Slice(node.children, func(i, j int) bool {
 			// If the plays exist already, sort them by value so more
@@ -386,12 +449,140 @@ func (s *Solver) findBestSequence(endNode *GameNode) []*move.Move {
 	return seq
 }
 
+// extendSequence completes a sequence that ends in a position whose value
+// came from the transposition table, by following the best moves stored
+// in the table.
+func (s *Solver) extendSequence(seq []*move.Move, endNode *GameNode) []*move.Move {
+	if s.ttable == nil || len(seq) >= endNode.heuristicValue.sequenceLength {
+		return seq
+	}
+	for _, m := range seq {
+		s.game.PlayMove(m, false, 0)
+	}
+	played := len(seq)
+	for len(seq) < endNode.heuristicValue.sequenceLength &&
+		s.game.Playing() != pb.PlayState_GAME_OVER {
+
+		entry, ok := s.ttable.lookup(s.zobrist.hash(s.game))
+		if !ok {
+			break
+		}
+		onTurn := s.game.PlayerOnTurn()
+		s.movegen.GenAll(s.game.RackFor(onTurn), false)
+		var next *move.Move
+		for _, m := range s.addPass(s.movegen.Plays(), onTurn) {
+			if moveHash(m) == entry.bestMove {
+				next = m
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		s.game.PlayMove(next, false, 0)
+		played++
+		seq = append(seq, next)
+	}
+	for i := 0; i < played; i++ {
+		s.game.UnplayLastMove()
+	}
+	return seq
+}
+
+// searchRoot searches the root node to the given depth. It returns the
+// winning node, and the solver whose transposition table has the rest of
+// its sequence.
+func (s *Solver) searchRoot(depth int) (*GameNode, *Solver) {
+	if s.threads > 1 || s.rankedMoves != 0 {
+		return s.searchRootMoves(depth)
+	}
+	α, β := float32(-Infinity), float32(Infinity)
+	if s.scouting() && s.rootNode.children != nil {
+		// We searched a depth already; the value is likely to be close.
+		prev := s.rootNode.heuristicValue.value
+		α, β = prev-aspirationWindow, prev+aspirationWindow
+	}
+	for {
+		wn := s.alphabeta(s.rootNode, depth, α, β, true)
+		if s.stopped {
+			return wn, s
+		}
+		// If the value is outside of the window, it is only a bound;
+		// search again with that side of the window open.
+		v := wn.heuristicValue.value
+		if v <= α && α > -Infinity {
+			α = float32(-Infinity)
+		} else if v >= β && β < Infinity {
+			β = float32(Infinity)
+		} else {
+			return wn, s
+		}
+		log.Debug().Msgf("Value %v outside of aspiration window, searching again", v)
+	}
+}
+
+// scouting returns true if moves may be searched with null or narrowed
+// windows.
+func (s *Solver) scouting() bool {
+	return s.negascout && !s.disablePruning
+}
+
+// shouldStop returns true if the search has to stop, because its context
+// was cancelled or its time is up. The context is only checked every so
+// many nodes, as that is not free.
+func (s *Solver) shouldStop() bool {
+	if s.stopped {
+		return true
+	}
+	s.nodesSinceCheck++
+	if s.nodesSinceCheck < stopCheckInterval {
+		return false
+	}
+	s.nodesSinceCheck = 0
+	select {
+	case <-s.ctx.Done():
+		s.stopped = true
+	default:
+	}
+	return s.stopped
+}
+
 // Solve solves the endgame given the current state of s.game, for the
 // current player whose turn it is in that state.
-func (s *Solver) Solve(plies int) (float32, []*move.Move, error) {
+// If the context is cancelled or the time limit is reached, the search
+// stops, and the result of the deepest search that finished is returned.
+// With iterative deepening off, that is only the search to `plies`. An
+// error is returned if no search finished.
+func (s *Solver) Solve(ctx context.Context, plies int) (float32, []*move.Move, error) {
+	s.stats = endgame.Stats{}
 	if s.game.Bag().TilesRemaining() > 0 {
 		return 0, nil, errors.New("bag is not empty; cannot use endgame solver")
 	}
+	if s.timeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeLimit)
+		defer cancel()
+	}
+	s.ctx = ctx
+	s.stopped = false
+	s.nodesSinceCheck = 0
+	s.totalNodes = 0
+	s.rootResults = nil
+	s.rankings = nil
+	s.clearOrdering()
+	start := time.Now()
+
+	// There are no challenges in the search; going out ends the game right
+	// away. The game can also end on passes during the search, which
+	// changes the history's play state, so put that back afterwards too.
+	if h := s.game.History(); h != nil {
+		rule, state := h.ChallengeRule, h.PlayState
+		h.ChallengeRule = pb.ChallengeRule_VOID
+		defer func() {
+			h.ChallengeRule = rule
+			h.PlayState = state
+		}()
+	}
 
 	// Generate children moves.
 	s.movegen.SetSortingParameter(movegen.SortByNone)
@@ -401,6 +592,7 @@ func (s *Solver) Solve(plies int) (float32, []*move.Move, error) {
 	// technically the children are the actual board _states_ but
 	// we don't keep track of those exactly
 	s.rootNode = &GameNode{}
+	s.pvNode = nil
 	// the root node is basically the board state prior to making any moves.
 	// the children of these nodes are the board states after every move.
 	// however we treat the children as those actual moves themsselves.
@@ -410,46 +602,147 @@ func (s *Solver) Solve(plies int) (float32, []*move.Move, error) {
 	s.maximizingPlayer = s.game.PlayerOnTurn()
 	log.Debug().Msgf("Spread at beginning of endgame: %v", s.initialSpread)
 	log.Debug().Msgf("Maximizing player is: %v", s.maximizingPlayer)
-	var bestV float32
-	var bestNode *GameNode
-	// XXX: We're going to need some sort of channel here to control
-	// deepening and propagate results.
+	s.prepareTable()
+	if s.threads > 1 {
+		err := s.makeWorkers()
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+
+	depths := []int{plies}
 	if s.iterativeDeepeningOn {
 		log.Debug().Msgf("Using iterative deepening with %v max plies", plies)
+		depths = depths[:0]
 		for p := 1; p <= plies; p++ {
-			log.Debug().Msgf("Spread at beginning of endgame: %v", s.game.CurrentSpread())
-			log.Debug().Msgf("Maximizing player is: %v", s.game.PlayerOnTurn())
-			bestNode = s.alphabeta(s.rootNode, p, float32(-Infinity), float32(Infinity), true)
-			bestV = bestNode.heuristicValue.value
-			bestSeq := s.findBestSequence(bestNode)
-			// Sort our plays by heuristic value for the next iteration, so that
-			// more promising nodes are searched first.
-			// sort.Slice(s.rootNode.children, func(i, j int) bool {
-			// 	return s.rootNode.children[i].heuristicValue >
-			// 		s.rootNode.children[j].heuristicValue
-			// })
-			// s.clearChildrenValues(s.rootNode)
-			log.Info().Msgf("Spread swing estimate found after %v plies: %v",
-				p, bestV)
-			log.Info().Msgf("Best seq so far is %v", bestSeq)
+			depths = append(depths, p)
 		}
-	} else {
-		bestNode = s.alphabeta(s.rootNode, plies, float32(-Infinity), float32(Infinity), true)
+	}
+	var bestV float32
+	var bestSeq []*move.Move
+	completed := 0
+	for _, p := range depths {
+		bestNode, pvSolver := s.searchRoot(p)
+		if s.stopped {
+			log.Debug().Msgf("Search stopped while searching %v plies", p)
+			break
+		}
+		completed = p
+		s.pvNode = bestNode
 		bestV = bestNode.heuristicValue.value
+		// Go down tree and find best variation:
+		bestSeq = pvSolver.extendSequence(s.findBestSequence(bestNode), bestNode)
+		if s.rankedMoves != 0 {
+			s.rankRootMoves()
+		}
+		log.Debug().Msgf("Spread swing estimate found after %v plies: %v",
+			p, bestV)
+		log.Debug().Msgf("Best seq so far is %v", bestSeq)
+		if s.progressCallback != nil {
+			s.progressCallback(Progress{
+				Depth:    p,
+				Nodes:    s.totalNodes,
+				Value:    bestV,
+				Sequence: bestSeq,
+				Elapsed:  time.Since(start),
+			})
+		}
+	}
+	s.stats = endgame.Stats{Nodes: s.totalNodes, Depth: completed, Elapsed: time.Since(start)}
+	if completed == 0 {
+		return 0, nil, ctx.Err()
 	}
-	log.Info().Msgf("Best spread found: %v", bestNode.heuristicValue.value)
-	// Go down tree and find best variation:
-	bestSeq := s.findBestSequence(bestNode)
+	log.Debug().Msgf("Best spread found: %v", bestV)
 	log.Debug().Msgf("Number of expanded nodes: %v", s.totalNodes)
+	if s.ttable != nil {
+		log.Debug().Msgf("Transposition table lookups: %v, hits: %v",
+			s.ttable.lookups, s.ttable.hits)
+	}
 	log.Debug().Msgf("Best sequence: (len=%v) %v", len(bestSeq), bestSeq)
 
 	return bestV, bestSeq, nil
 }
 
+// spreadOffset is the spread the maximizing player has gained since the
+// start of the endgame, in the current position.
+func (s *Solver) spreadOffset() float32 {
+	other := (s.maximizingPlayer + 1) % s.game.NumPlayers()
+	spread := s.game.PointsFor(s.maximizingPlayer) - s.game.PointsFor(other)
+	return float32(spread - s.initialSpread)
+}
+
+// probe looks the current position up in the transposition table. It
+// returns the best move found for it before (0 if none), and whether the
+// entry settles the value of the node, in which case the node's value is
+// set. Otherwise, α and β may be narrowed by the bounds in the entry.
+func (s *Solver) probe(node *GameNode, key uint64, depth int, α, β *float32) (
+	uint64, bool) {
+
+	entry, ok := s.ttable.lookup(key)
+	if !ok {
+		return 0, false
+	}
+	// Never cut off at the root; we need to know the best move there.
+	if node.move == nil || int(entry.depth) < depth {
+		return entry.bestMove, false
+	}
+	v := entry.gain + s.spreadOffset()
+	switch entry.flag {
+	case ttLower:
+		*α = max(*α, v)
+	case ttUpper:
+		*β = min(*β, v)
+	}
+	if entry.flag != ttExact && *α < *β {
+		return entry.bestMove, false
+	}
+	node.heuristicValue = nodeValue{
+		value:          v,
+		knownEnd:       entry.knownEnd,
+		sequenceLength: int(entry.seqLength) + s.game.Turn() - s.initialTurnNum,
+	}
+	return entry.bestMove, true
+}
+
+// record stores the result of searching the current position. α and β
+// are the bounds the node's children were searched with.
+func (s *Solver) record(node, winningNode *GameNode, key uint64, depth int,
+	α, β float32) {
+
+	flag := ttExact
+	value := node.heuristicValue.value
+	if value <= α {
+		flag = ttUpper
+	} else if value >= β {
+		flag = ttLower
+	}
+	// The best move is the child of this node that leads to the winning
+	// node.
+	best := winningNode
+	for best.parent != node {
+		best = best.parent
+	}
+	s.ttable.store(ttEntry{
+		key:       key,
+		bestMove:  moveHash(best.move),
+		gain:      value - s.spreadOffset(),
+		depth:     int8(depth),
+		flag:      flag,
+		knownEnd:  node.heuristicValue.knownEnd,
+		seqLength: int8(node.heuristicValue.sequenceLength - (s.game.Turn() - s.initialTurnNum)),
+	})
+}
+
 func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 	maximizingPlayer bool) *GameNode {
 
 	// depthDbg := strings.Repeat(" ", depth)
+	if s.shouldStop() {
+		// The value doesn't matter; the search we are in is thrown away.
+		return node
+	}
+	node.alpha, node.beta = α, β
+	node.cutoff = false
 	if depth == 0 || s.game.Playing() == pb.PlayState_GAME_OVER {
 		// s.game.Playing() happens if the game is over; i.e. if the
 		// current node is terminal.
@@ -459,10 +752,21 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 		return node
 	}
 
+	var key, ttMove uint64
+	if s.ttable != nil {
+		key = s.zobrist.hash(s.game)
+		var found bool
+		ttMove, found = s.probe(node, key, depth, &α, &β)
+		if found {
+			return node
+		}
+	}
+	searchedα, searchedβ := α, β
+
 	if maximizingPlayer {
 		value := float32(-Infinity)
 		var winningNode *GameNode
-		iter := s.childGenerator(node, maximizingPlayer)
+		iter := s.childGenerator(node, depth, maximizingPlayer, ttMove)
 		for child, newNode := iter(); child != nil; child, newNode = iter() {
 			// Play the child
 			// log.Debug().Msgf("%vGoing to play move %v", depthDbg, child.move)
@@ -470,8 +774,21 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 			child.move.SetVisited(true)
 			// log.Debug().Msgf("%vState is now %v", depthDbg,
 			// s.game.String())
-			wn := s.alphabeta(child, depth-1, α, β, false)
+			var wn *GameNode
+			if winningNode != nil && s.scouting() {
+				// Prove that this move is no better than the best one so
+				// far, and only search it fully if it is.
+				wn = s.alphabeta(child, depth-1, α, α+nullWindow, false)
+				if v := wn.heuristicValue.value; v > α && v < β && !s.stopped {
+					wn = s.alphabeta(child, depth-1, α, β, false)
+				}
+			} else {
+				wn = s.alphabeta(child, depth-1, α, β, false)
+			}
 			s.game.UnplayLastMove()
+			if s.stopped {
+				return wn
+			}
 			// log.Debug().Msgf("%vAfter unplay, state is now %v", depthDbg, s.game.String())
 
 			if wn.heuristicValue.value > value {
@@ -482,9 +799,15 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 			if newNode {
 				node.children = append(node.children, child)
 			}
+			if s.frontierCutoff(depth) {
+				node.cutoff = true
+				break
+			}
 			if !s.disablePruning {
 				α = max(α, value)
 				if α >= β {
+					s.recordCutoff(child.move, depth)
+					node.cutoff = true
 					break // beta cut-off
 				}
 			}
@@ -492,20 +815,34 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 		node.heuristicValue = nodeValue{
 			value: value, knownEnd: winningNode.heuristicValue.knownEnd,
 			sequenceLength: winningNode.heuristicValue.sequenceLength}
+		if s.ttable != nil {
+			s.record(node, winningNode, key, depth, searchedα, searchedβ)
+		}
 		return winningNode
 	}
 	// Otherwise, not maximizing
 	value := float32(Infinity)
 	var winningNode *GameNode
-	iter := s.childGenerator(node, maximizingPlayer)
+	iter := s.childGenerator(node, depth, maximizingPlayer, ttMove)
 	for child, newNode := iter(); child != nil; child, newNode = iter() {
 		// log.Debug().Msgf("%vGoing to play move %v", depthDbg, child.move)
 		s.game.PlayMove(child.move, false, 0)
 		child.move.SetVisited(true)
 		// log.Debug().Msgf("%vState is now %v", depthDbg,
 		// s.game.String())
-		wn := s.alphabeta(child, depth-1, α, β, true)
+		var wn *GameNode
+		if winningNode != nil && s.scouting() {
+			wn = s.alphabeta(child, depth-1, β-nullWindow, β, true)
+			if v := wn.heuristicValue.value; v > α && v < β && !s.stopped {
+				wn = s.alphabeta(child, depth-1, α, β, true)
+			}
+		} else {
+			wn = s.alphabeta(child, depth-1, α, β, true)
+		}
 		s.game.UnplayLastMove()
+		if s.stopped {
+			return wn
+		}
 		// log.Debug().Msgf("%vAfter unplay, state is now %v", depthDbg, s.game.String())
 		if wn.heuristicValue.value < value {
 			value = wn.heuristicValue.value
@@ -515,9 +852,15 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 		if newNode {
 			node.children = append(node.children, child)
 		}
+		if s.frontierCutoff(depth) {
+			node.cutoff = true
+			break
+		}
 		if !s.disablePruning {
 			β = min(β, value)
 			if α >= β {
+				s.recordCutoff(child.move, depth)
+				node.cutoff = true
 				break // alpha cut-off
 			}
 		}
@@ -525,6 +868,9 @@ func (s *Solver) alphabeta(node *GameNode, depth int, α float32, β float32,
 	node.heuristicValue = nodeValue{
 		value: value, knownEnd: winningNode.heuristicValue.knownEnd,
 		sequenceLength: winningNode.heuristicValue.sequenceLength}
+	if s.ttable != nil {
+		s.record(node, winningNode, key, depth, searchedα, searchedβ)
+	}
 	return winningNode
 }
 
@@ -540,6 +886,30 @@ func (s *Solver) SetPruningDisabled(i bool) {
 	s.disablePruning = i
 }
 
+// Stats returns the statistics of the last solve. The depth is the deepest
+// search that finished.
+func (s *Solver) Stats() endgame.Stats {
+	return s.stats
+}
+
 func (s *Solver) RootNode() *GameNode {
 	return s.rootNode
 }
+
+// SetTranspositionTableMB sets the size of the transposition table, in
+// megabytes. 0 turns the table off.
+func (s *Solver) SetTranspositionTableMB(mb int) {
+	s.ttableMB = mb
+	s.ttable = nil
+}
+
+// SetTimeLimit bounds the time Solve can take. A limit of 0 means no limit.
+func (s *Solver) SetTimeLimit(d time.Duration) {
+	s.timeLimit = d
+}
+
+// SetProgressCallback sets a function that is called every time the search
+// finishes a depth, with the best result so far.
+func (s *Solver) SetProgressCallback(f func(Progress)) {
+	s.progressCallback = f
+}
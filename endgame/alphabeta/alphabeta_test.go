@@ -1,9 +1,13 @@
 package alphabeta
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/matryer/is"
@@ -39,10 +43,24 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+// saveDotFile writes the whole search tree of the last solve as a Graphviz
+// graph, to look at when debugging.
+func saveDotFile(t *testing.T, s *Solver, filename string) {
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	err = WriteTreeDot(f, s.SearchTree(TreeOptions{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func setUpSolver(lex string, bvs board.VsWho, plies int, rack1, rack2 string,
 	p1pts, p2pts int, onTurn int) (*Solver, error) {
 
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard,
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
 		lex, DefaultConfig.DefaultLetterDistribution)
 
 	if err != nil {
@@ -108,7 +126,7 @@ func TestSolveComplex(t *testing.T) {
 		1)
 	is.NoErr(err)
 
-	v, _, _ := s.Solve(plies)
+	v, _, _ := s.Solve(context.Background(), plies)
 	is.Equal(v, 116)
 	// Quackle finds a 122-pt win. However, I think it's wrong because it
 	// doesn't take into account that opp can pass to prevent a setup
@@ -152,7 +170,7 @@ func TestSolveOther(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 	t.Fail()
 } */
@@ -192,7 +210,7 @@ func TestSolveOther2(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 	t.Fail()
 }
@@ -206,7 +224,7 @@ func TestSolveOther3(t *testing.T) {
 		1)
 	is.NoErr(err)
 
-	v, _, _ := s.Solve(plies)
+	v, _, _ := s.Solve(context.Background(), plies)
 	is.True(v > 0)
 }
 
@@ -221,7 +239,7 @@ func TestSolveStandard(t *testing.T) {
 		1)
 
 	is.NoErr(err)
-	v, _, _ := s.Solve(plies)
+	v, _, _ := s.Solve(context.Background(), plies)
 
 	is.Equal(v, float32(11))
 }
@@ -235,7 +253,7 @@ func TestSolveStandard2(t *testing.T) {
 		1)
 	is.NoErr(err)
 
-	v, _, _ := s.Solve(plies)
+	v, _, _ := s.Solve(context.Background(), plies)
 	is.Equal(v, float32(25))
 }
 
@@ -276,7 +294,7 @@ func TestSolveMaven(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 	// if v < 0 {
 	// 	t.Errorf("Expected > 0, %v was", v)
@@ -284,6 +302,195 @@ func TestSolveMaven(t *testing.T) {
 	t.Fail()
 } */
 
+func TestTranspositionTable(t *testing.T) {
+	is := is.New(t)
+	plies := 4
+	var values []float32
+	var firstMoves []string
+	var nodes []int
+	for _, mb := range []int{0, DefaultTranspositionTableMB} {
+		s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+			0)
+		is.NoErr(err)
+		s.SetTranspositionTableMB(mb)
+		v, seq, err := s.Solve(context.Background(), plies)
+		is.NoErr(err)
+		values = append(values, v)
+		firstMoves = append(firstMoves, seq[0].ShortDescription())
+		nodes = append(nodes, s.totalNodes)
+	}
+	// The table should not change the outcome, only how much we have to
+	// search to find it.
+	is.Equal(values[0], values[1])
+	is.Equal(firstMoves[0], firstMoves[1])
+	is.True(nodes[1] < nodes[0])
+}
+
+func TestParallelSolve(t *testing.T) {
+	is := is.New(t)
+	plies := 4
+	var values []float32
+	for _, threads := range []int{1, 3} {
+		s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+			0)
+		is.NoErr(err)
+		s.SetThreads(threads)
+		v, seq, err := s.Solve(context.Background(), plies)
+		is.NoErr(err)
+		is.True(len(seq) > 0)
+		values = append(values, v)
+	}
+	is.Equal(values[0], values[1])
+}
+
+func TestMoveOrdering(t *testing.T) {
+	is := is.New(t)
+	plies := 3
+	var values []float32
+	var nodes []int
+	for _, on := range []bool{false, true} {
+		s, err := setUpSolver("NWL18", board.VsCanik, plies, "DEHILOR", "BGIV", 389, 384,
+			1)
+		is.NoErr(err)
+		s.SetNegascout(on)
+		s.SetMoveOrdering(on)
+		v, _, err := s.Solve(context.Background(), plies)
+		is.NoErr(err)
+		values = append(values, v)
+		nodes = append(nodes, s.totalNodes)
+	}
+	is.Equal(values[0], values[1])
+	// 37789 nodes without, 3394 with.
+	is.True(nodes[1] < nodes[0]/5)
+}
+
+func TestSolveCancelled(t *testing.T) {
+	is := is.New(t)
+	plies := 4
+	s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+		0)
+	is.NoErr(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var progress []Progress
+	s.SetProgressCallback(func(p Progress) {
+		progress = append(progress, p)
+		if p.Depth == 2 {
+			cancel()
+		}
+	})
+	v, seq, err := s.Solve(ctx, plies)
+	is.NoErr(err)
+	// We get the result of the deepest search that finished.
+	last := progress[len(progress)-1]
+	is.True(last.Depth >= 2)
+	is.True(last.Depth < plies)
+	is.Equal(v, last.Value)
+	is.Equal(len(seq), len(last.Sequence))
+	is.Equal(seq[0], last.Sequence[0])
+}
+
+func TestRankedMoves(t *testing.T) {
+	is := is.New(t)
+	// Ranking every move is a lot more work than finding the best one, so
+	// keep the search shallow.
+	plies := 2
+	s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+		0)
+	is.NoErr(err)
+	best, _, err := s.Solve(context.Background(), plies)
+	is.NoErr(err)
+
+	var topValues []float32
+	for _, k := range []int{-1, 3} {
+		s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+			0)
+		is.NoErr(err)
+		s.SetRankedMoves(k)
+		v, _, err := s.Solve(context.Background(), plies)
+		is.NoErr(err)
+		is.Equal(v, best)
+
+		ranked := s.RankedMoves()
+		if k > 0 {
+			is.Equal(len(ranked), k)
+		} else {
+			is.True(len(ranked) > 3)
+		}
+		is.Equal(ranked[0].Value, best)
+		for i, r := range ranked {
+			is.True(r.Exact)
+			is.Equal(r.FinalSpread, float32(412-371)+r.Value)
+			is.True(len(r.Sequence) > 0)
+			is.Equal(r.Sequence[0], r.Move)
+			if i > 0 {
+				is.True(r.Value <= ranked[i-1].Value)
+			}
+		}
+		topValues = append(topValues, ranked[0].Value, ranked[1].Value, ranked[2].Value)
+	}
+	// The top 3 are the same whether we rank all the moves or just them.
+	is.Equal(topValues[:3], topValues[3:])
+}
+
+func TestSearchTree(t *testing.T) {
+	is := is.New(t)
+	plies := 3
+	s, err := setUpSolver("NWL18", board.VsJoey, plies, "DIV", "AEFILMR", 412, 371,
+		0)
+	is.NoErr(err)
+	v, seq, err := s.Solve(context.Background(), plies)
+	is.NoErr(err)
+
+	tree := s.SearchTree(TreeOptions{})
+	is.Equal(tree.Move, "")
+	is.Equal(tree.Value, v)
+	is.True(tree.PV)
+	is.True(len(tree.Children) > 1)
+
+	// Only the PV is expanded, and it follows the best sequence.
+	pvTree := s.SearchTree(TreeOptions{PVOnly: true})
+	n := pvTree
+	for _, m := range seq {
+		var next *TreeNode
+		for _, c := range n.Children {
+			if c.PV {
+				is.True(next == nil)
+				next = c
+			} else {
+				is.Equal(len(c.Children), 0)
+			}
+		}
+		if next == nil {
+			// The rest of the sequence came from the transposition table.
+			break
+		}
+		is.Equal(next.Move, m.ShortDescription())
+		is.True(next.Alpha <= next.Beta)
+		n = next
+	}
+	is.True(n != pvTree)
+
+	// With a depth, nothing deeper is exported.
+	shallow := s.SearchTree(TreeOptions{MaxDepth: 1})
+	is.Equal(len(shallow.Children), len(tree.Children))
+	for _, c := range shallow.Children {
+		is.Equal(len(c.Children), 0)
+	}
+
+	var js bytes.Buffer
+	is.NoErr(WriteTreeJSON(&js, shallow))
+	var decoded TreeNode
+	is.NoErr(json.Unmarshal(js.Bytes(), &decoded))
+	is.Equal(&decoded, shallow)
+
+	var dot bytes.Buffer
+	is.NoErr(WriteTreeDot(&dot, shallow))
+	is.True(strings.HasPrefix(dot.String(), "digraph {"))
+	// One edge for every child of the root.
+	is.Equal(strings.Count(dot.String(), "->"), len(shallow.Children))
+}
+
 func TestStuck(t *testing.T) {
 	is := is.New(t)
 
@@ -422,11 +629,9 @@ func TestMinimalCase(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
-	dot := &dotfile{}
-	genDotFile(s.rootNode, dot)
-	saveDotFile(s.rootNode, dot, "out.dot")
+	saveDotFile(t, s, "out.dot")
 
 	if v < 0 {
 		t.Errorf("Expected > 0, %v was", v)
@@ -471,12 +676,10 @@ func TestMinimalCase2(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 
-	// dot := &dotfile{}
-	// genDotFile(s.rootNode, dot)
-	// saveDotFile(s.rootNode, dot, "out.dot")
+	// saveDotFile(t, s, "out.dot")
 
 	if v != 11 {
 		t.Errorf("Expected 11-pt spread swing, found %v", v)
@@ -521,12 +724,10 @@ func TestMinimalCase3(t *testing.T) {
 	game.SetPlayerOnTurn(0)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 
-	// dot := &dotfile{}
-	// genDotFile(s.rootNode, dot)
-	// saveDotFile(s.rootNode, dot, "out.dot")
+	// saveDotFile(t, s, "out.dot")
 
 	if v != 11 {
 		t.Errorf("Expected 11-pt spread swing, found %v", v)
@@ -570,12 +771,10 @@ func TestMinimalCase4(t *testing.T) {
 	game.SetPlayerOnTurn(1)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 
-	// dot := &dotfile{}
-	// genDotFile(s.rootNode, dot)
-	// saveDotFile(s.rootNode, dot, "out.dot")
+	// saveDotFile(t, s, "out.dot")
 
 	if v != 11 {
 		t.Errorf("Expected 11-pt spread swing, found %v", v)
@@ -624,7 +823,7 @@ func TestAnotherOneTiler(t *testing.T) {
 	game.SetPlayerOnTurn(0)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 	// if v < 0 {
 	// 	t.Errorf("Expected > 0, %v was", v)
@@ -668,7 +867,7 @@ func TestYetAnotherOneTiler(t *testing.T) {
 	game.SetPlayerOnTurn(0)
 	game.SetPlaying(true)
 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-	v, _ := s.Solve(plies)
+	v, _ := s.Solve(context.Background(), plies)
 	fmt.Println("Value found", v)
 	// if v < 0 {
 	// 	t.Errorf("Expected > 0, %v was", v)
@@ -682,7 +881,7 @@ func TestProperIterativeDeepening(t *testing.T) {
 	is := is.New(t)
 	// Should get the same result with 7 or 8 plies.
 	plyCount := []int{7, 8}
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard,
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
 		"NWL18", "English")
 	is.NoErr(err)
 	for _, plies := range plyCount {
@@ -716,7 +915,7 @@ func TestProperIterativeDeepening(t *testing.T) {
 		// Prior to solving the endgame, set to simulation mode.
 		g.SetBackupMode(game.SimulationMode)
 		g.SetStateStackLength(plies)
-		v, seq, _ := s.Solve(plies)
+		v, seq, _ := s.Solve(context.Background(), plies)
 		is.Equal(v, float32(44))
 		// In particular, the sequence should start with 6I A.
 		// Player on turn needs to block the P spot. Anything else
@@ -730,7 +929,7 @@ func TestFromGCG(t *testing.T) {
 	plies := 3
 	is := is.New(t)
 
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard,
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
 		"CSW19", "English")
 
 	gameHistory, err := gcgio.ParseGCG(&DefaultConfig, "../../gcgio/testdata/vs_frentz.gcg")
@@ -756,7 +955,7 @@ func TestFromGCG(t *testing.T) {
 	// s.iterativeDeepeningOn = false
 	// s.simpleEvaluation = true
 	fmt.Println(g.Board().ToDisplayText(g.Alphabet()))
-	v, seq, _ := s.Solve(plies)
+	v, seq, _ := s.Solve(context.Background(), plies)
 	is.Equal(v, float32(99))
 	is.Equal(len(seq), 1)
 	// t.Fail()
@@ -824,7 +1023,7 @@ func TestFromGCG(t *testing.T) {
 // 	s := new(Solver)
 // 	s.Init(generator, game)
 // 	fmt.Println(game.Board().ToDisplayText(game.Alphabet()))
-// 	v, seq := s.Solve(plies)
+// 	v, seq := s.Solve(context.Background(), plies)
 // 	if v != 44 {
 // 		t.Errorf("Spread is wrong: %v", v)
 // 	}
@@ -834,8 +1033,6 @@ func TestFromGCG(t *testing.T) {
 // 		// shows a serious bug.
 // 		t.Errorf("Sequence is wrong: %v", seq)
 // 	}
-// 	dot := &dotfile{}
-// 	genDotFile(s.rootNode, dot)
-// 	saveDotFile(s.rootNode, dot, "out.dot")
+// 	saveDotFile(t, s, "out.dot")
 // }
 */
@@ -48,6 +48,11 @@ type GameNode struct {
 	heuristicValue nodeValue
 	children       []*GameNode // children should be null until expanded.
 	generatedPlays []*move.Move
+	// alpha and beta are the window the node was last searched with, and
+	// cutoff is whether that search stopped before trying all the moves.
+	alpha  float32
+	beta   float32
+	cutoff bool
 }
 
 func (g *GameNode) Children() []*GameNode {
@@ -67,8 +72,9 @@ func (g *GameNode) GeneratedPlays() []*move.Move {
 }
 
 func (g *GameNode) String() string {
-	return fmt.Sprintf("<gamenode move %v, heuristicVal %v, nchild %v>", g.move,
-		g.heuristicValue, len(g.children))
+	return fmt.Sprintf("<gamenode move %v, heuristicVal %v, nchild %v, window [%v, %v], cutoff %v>",
+		g.move, g.heuristicValue, len(g.children), boundString(g.alpha), boundString(g.beta),
+		g.cutoff)
 }
 
 func (g *GameNode) value(s *Solver) nodeValue {
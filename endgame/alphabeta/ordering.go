@@ -0,0 +1,125 @@
+package alphabeta
+
+import (
+	"sort"
+
+	"github.com/domino14/macondo/move"
+)
+
+const (
+	// numKillers is how many killer moves are kept for every ply.
+	numKillers = 2
+	// aspirationWindow is how far from the value of the previous depth
+	// the root is first searched, in points of spread.
+	aspirationWindow = 10
+	// nullWindow is the width of the window used to test whether a move
+	// beats the best one so far. Values are spreads, but with the
+	// heuristic evaluation they are not whole numbers, so the window
+	// just needs to be small.
+	nullWindow = 0.001
+)
+
+// SetNegascout turns principal variation search (also known as
+// negascout) on or off. On by default. After the first move of a node,
+// every other move is only searched with a null window, to prove that it
+// is no better than the best move so far; only if that fails is it
+// searched again with the full window. With iterative deepening, the root
+// is also first searched in a window around the value of the last depth.
+func (s *Solver) SetNegascout(on bool) {
+	s.negascout = on
+}
+
+// SetMoveOrdering turns the move ordering improvements on or off. On by
+// default. Moves that caused a cut-off before are tried early: the last
+// ones that did so at the same ply (killer moves) first, then the ones
+// that did so the most often, anywhere in the tree (the history). Also,
+// at the nodes right above the leaves, the plays are left in the order of
+// their valuation, which is the order of the leaves' values; so only the
+// first one needs to be searched.
+func (s *Solver) SetMoveOrdering(on bool) {
+	s.moveOrdering = on
+}
+
+// clearOrdering forgets the killer moves and the history, for a new solve.
+func (s *Solver) clearOrdering() {
+	s.killers = s.killers[:0]
+	s.history = map[uint64]int{}
+}
+
+// ply returns how many turns have been played since the root.
+func (s *Solver) ply() int {
+	return s.game.Turn() - s.initialTurnNum
+}
+
+// recordCutoff remembers that the move caused a cut-off at the current
+// ply, when searching to the given depth.
+func (s *Solver) recordCutoff(m *move.Move, depth int) {
+	if !s.moveOrdering {
+		return
+	}
+	h := moveHash(m)
+	ply := s.ply()
+	for len(s.killers) <= ply {
+		s.killers = append(s.killers, [numKillers]uint64{})
+	}
+	k := &s.killers[ply]
+	if k[0] != h {
+		copy(k[1:], k[:numKillers-1])
+		k[0] = h
+	}
+	// Cut-offs close to the root save the most work.
+	s.history[h] += depth * depth
+}
+
+// orderPlays puts the freshly generated plays of a node in the order they
+// are to be searched. They come sorted by their static valuation. The
+// plays with the best history go first, keeping that order among plays
+// with the same history; then the killer moves of this ply go in front of
+// them, and the best move from the transposition table in front of all.
+func (s *Solver) orderPlays(plays []*move.Move, ttMove uint64) {
+	if s.moveOrdering {
+		if len(s.history) > 0 {
+			scores := make(map[*move.Move]int, len(plays))
+			for _, p := range plays {
+				scores[p] = s.history[moveHash(p)]
+			}
+			sort.SliceStable(plays, func(i, j int) bool {
+				return scores[plays[i]] > scores[plays[j]]
+			})
+		}
+		if ply := s.ply(); ply < len(s.killers) {
+			for i := numKillers - 1; i >= 0; i-- {
+				moveToFront(plays, s.killers[ply][i])
+			}
+		}
+	}
+	moveToFront(plays, ttMove)
+}
+
+// frontierCutoff returns true if a node at the given depth can stop
+// searching after its first child. At depth 1, the children are leaves,
+// and the value of a leaf only depends on the valuation of its play, which
+// the plays are sorted by. The exceptions are a simple evaluation, where
+// going out is valued differently, and a pass that ends the game on
+// scoreless turns, which is valued as the end of the game.
+func (s *Solver) frontierCutoff(depth int) bool {
+	return depth == 1 && s.moveOrdering && !s.disablePruning &&
+		!s.simpleEvaluation && s.game.ScorelessTurns() < 5
+}
+
+// moveToFront moves the play with the given hash to the front of the
+// plays, keeping the others in order.
+func moveToFront(plays []*move.Move, h uint64) {
+	if h == 0 {
+		return
+	}
+	for i, p := range plays {
+		if moveHash(p) != h {
+			continue
+		}
+		for ; i > 0; i-- {
+			plays[i], plays[i-1] = plays[i-1], plays[i]
+		}
+		return
+	}
+}
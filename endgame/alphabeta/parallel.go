@@ -0,0 +1,181 @@
+package alphabeta
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/domino14/macondo/game"
+	"github.com/domino14/macondo/movegen"
+)
+
+// SetThreads sets the number of threads to search with. With more than one
+// thread, the moves at the root are split among the threads, and every
+// thread searches its moves with its own copy of the game. The best value
+// found is the same as with one thread, but if several moves share the
+// best value, a different one of them might be returned.
+func (s *Solver) SetThreads(threads int) {
+	if threads < 1 {
+		threads = 1
+	}
+	s.threads = threads
+}
+
+// makeWorkers sets up a solver for every thread, each with a copy of the
+// game in its current (root) state.
+func (s *Solver) makeWorkers() error {
+	s.workers = make([]*Solver, s.threads)
+	for t := range s.workers {
+		g := s.game.Copy()
+		g.SetBackupMode(game.SimulationMode)
+		mg, err := movegen.NewGeneratorForGame(g)
+		if err != nil {
+			return err
+		}
+		mg.SetSortingParameter(movegen.SortByNone)
+
+		w := &Solver{}
+		err = w.Init(mg, g)
+		if err != nil {
+			return err
+		}
+		w.simpleEvaluation = s.simpleEvaluation
+		w.disablePruning = s.disablePruning
+		w.negascout = s.negascout
+		w.moveOrdering = s.moveOrdering
+		w.clearOrdering()
+		// The memory cap is for all the threads together.
+		w.ttableMB = s.ttableMB / s.threads
+		w.initialSpread = s.initialSpread
+		w.initialTurnNum = s.initialTurnNum
+		w.maximizingPlayer = s.maximizingPlayer
+		w.ctx = s.ctx
+		w.prepareTable()
+		s.workers[t] = w
+	}
+	return nil
+}
+
+// rootResult is the result of searching one move at the root.
+type rootResult struct {
+	// node is the winning node of the move's search.
+	node *GameNode
+	// solver is the solver that searched the move, whose transposition
+	// table has the rest of its sequence.
+	solver *Solver
+	// exact is false if the move's value is only an upper bound, as it did
+	// not beat the bound it was searched with.
+	exact bool
+}
+
+// searchRootMoves searches the root to the given depth, one root move at a
+// time, with the moves split among the workers (or just this solver, with
+// one thread). Every move is searched with a lower bound: the best value
+// found so far by any worker, or when ranking the top k moves, the k-th
+// best. Moves that don't beat their bound only get an upper bound on their
+// value. It returns the winning node and the solver that found it, or nils
+// if the search was stopped; the result for every move is kept in
+// s.rootResults.
+func (s *Solver) searchRootMoves(depth int) (*GameNode, *Solver) {
+	root := s.rootNode
+	root.alpha, root.beta = float32(-Infinity), float32(Infinity)
+	root.cutoff = false
+	if root.children == nil {
+		root.generatedPlays = s.generateSTMPlays(root)
+		for _, play := range root.generatedPlays {
+			root.children = append(root.children, &GameNode{move: play, parent: root})
+		}
+		s.totalNodes += len(root.children)
+	} else {
+		// Search the most promising moves from the last iteration first.
+		sort.SliceStable(root.children, func(i, j int) bool {
+			return root.children[j].heuristicValue.less(root.children[i].heuristicValue)
+		})
+	}
+	workers := s.workers
+	if s.threads <= 1 {
+		workers = []*Solver{s}
+	}
+	k := s.rankedMoves
+	if k == 0 {
+		k = 1
+	}
+
+	var mu sync.Mutex
+	next := 0
+	// exactValues are the values of the moves that beat their bound, from
+	// best to worst.
+	var exactValues []float32
+	results := make([]rootResult, len(root.children))
+	var winner *rootResult
+
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *Solver) {
+			defer wg.Done()
+			for {
+				mu.Lock()
+				if next == len(root.children) || w.stopped {
+					mu.Unlock()
+					return
+				}
+				idx := next
+				child := root.children[idx]
+				next++
+				α := float32(-Infinity)
+				if k > 0 && len(exactValues) >= k && !s.disablePruning {
+					α = exactValues[k-1]
+				}
+				mu.Unlock()
+
+				w.game.PlayMove(child.move, false, 0)
+				child.move.SetVisited(true)
+				wn := w.alphabeta(child, depth-1, α, float32(Infinity), false)
+				w.game.UnplayLastMove()
+				if w.stopped {
+					return
+				}
+
+				mu.Lock()
+				v := wn.heuristicValue.value
+				results[idx] = rootResult{node: wn, solver: w, exact: v > α}
+				if v > α {
+					i := sort.Search(len(exactValues), func(i int) bool {
+						return exactValues[i] < v
+					})
+					exactValues = append(exactValues, 0)
+					copy(exactValues[i+1:], exactValues[i:])
+					exactValues[i] = v
+				}
+				// A move that did not beat its bound only has an upper
+				// bound on its value, so only a strictly better value can
+				// replace the winner.
+				if winner == nil || v > winner.node.heuristicValue.value {
+					winner = &results[idx]
+				}
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, w := range workers {
+		if w == s {
+			continue
+		}
+		s.totalNodes += w.totalNodes
+		w.totalNodes = 0
+		if w.stopped {
+			s.stopped = true
+		}
+	}
+	if s.stopped {
+		return nil, nil
+	}
+	s.rootResults = results
+	winningNode := winner.node
+	root.heuristicValue = nodeValue{
+		value: winningNode.heuristicValue.value, knownEnd: winningNode.heuristicValue.knownEnd,
+		sequenceLength: winningNode.heuristicValue.sequenceLength}
+	return winningNode, winner.solver
+}
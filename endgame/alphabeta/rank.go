@@ -0,0 +1,69 @@
+package alphabeta
+
+import (
+	"sort"
+
+	"github.com/domino14/macondo/move"
+)
+
+// RankedMove is the result of the search for one move at the root.
+type RankedMove struct {
+	Move *move.Move
+	// Value is the spread the maximizing player gains in the endgame by
+	// playing this move.
+	Value float32
+	// FinalSpread is the maximizing player's spread at the end of the
+	// sequence.
+	FinalSpread float32
+	// Exact is false if Value is only an upper bound. That happens when
+	// ranking the top k moves; a move that can't make the top k is not
+	// searched any further than is needed to show that.
+	Exact bool
+	// Sequence is the best sequence that starts with this move.
+	Sequence []*move.Move
+}
+
+// SetRankedMoves makes the solver rank the moves at the root by their
+// value, each with its own best sequence. With k > 0, only the top k moves
+// get exact values; the rest only get an upper bound, which prunes a lot
+// more. With k < 0, every move gets an exact value. With k = 0 (the
+// default), only the best move is searched for.
+func (s *Solver) SetRankedMoves(k int) {
+	s.rankedMoves = k
+}
+
+// RankedMoves returns the moves ranked by the last Solve, from best to
+// worst, from the deepest search that was finished. Moves with exact values
+// come first.
+func (s *Solver) RankedMoves() []RankedMove {
+	return s.rankings
+}
+
+// rankRootMoves builds the rankings from the results of the last search of
+// the root.
+func (s *Solver) rankRootMoves() {
+	rankings := []RankedMove{}
+	for i, r := range s.rootResults {
+		if r.node == nil {
+			continue
+		}
+		value := r.node.heuristicValue.value
+		rankings = append(rankings, RankedMove{
+			Move:        s.rootNode.children[i].move,
+			Value:       value,
+			FinalSpread: float32(s.initialSpread) + value,
+			Exact:       r.exact,
+			Sequence:    r.solver.extendSequence(s.findBestSequence(r.node), r.node),
+		})
+	}
+	sort.SliceStable(rankings, func(i, j int) bool {
+		if rankings[i].Exact != rankings[j].Exact {
+			return rankings[i].Exact
+		}
+		return rankings[i].Value > rankings[j].Value
+	})
+	if s.rankedMoves > 0 && len(rankings) > s.rankedMoves {
+		rankings = rankings[:s.rankedMoves]
+	}
+	s.rankings = rankings
+}
@@ -0,0 +1,213 @@
+package alphabeta
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"unsafe"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+)
+
+// DefaultTranspositionTableMB is the default size of the transposition
+// table, in megabytes.
+const DefaultTranspositionTableMB = 64
+
+// zobristSeed is fixed so that the same position always hashes to the
+// same key, and searches are reproducible.
+const zobristSeed = 0x6d61636f6e646f
+
+// zobrist holds the random keys used to hash a position. A position is
+// the contents of the board, both racks, the side to move, and the number
+// of scoreless turns (as that decides when the game ends on passes).
+type zobrist struct {
+	dim int
+	// boardKeys is indexed by square, then letter. Blanks that have been
+	// designated a letter come after the natural letters.
+	boardKeys [][]uint64
+	// rackKeys is indexed by player, letter, then the number of that
+	// letter on the rack.
+	rackKeys      [2][][]uint64
+	sideToMoveKey uint64
+	waitingKey    uint64
+	scorelessKeys []uint64
+}
+
+func newZobrist(dim int) *zobrist {
+	r := rand.New(rand.NewSource(zobristSeed))
+	z := &zobrist{dim: dim}
+	z.boardKeys = make([][]uint64, dim*dim)
+	for sq := range z.boardKeys {
+		z.boardKeys[sq] = make([]uint64, 2*alphabet.MaxAlphabetSize)
+		for l := range z.boardKeys[sq] {
+			z.boardKeys[sq][l] = r.Uint64()
+		}
+	}
+	for p := range z.rackKeys {
+		z.rackKeys[p] = make([][]uint64, alphabet.MaxAlphabetSize+1)
+		for l := range z.rackKeys[p] {
+			z.rackKeys[p][l] = make([]uint64, game.RackTileLimit+1)
+			for ct := range z.rackKeys[p][l] {
+				z.rackKeys[p][l][ct] = r.Uint64()
+			}
+		}
+	}
+	z.sideToMoveKey = r.Uint64()
+	z.waitingKey = r.Uint64()
+	// A game ends after 6 scoreless turns.
+	z.scorelessKeys = make([]uint64, 7)
+	for i := range z.scorelessKeys {
+		z.scorelessKeys[i] = r.Uint64()
+	}
+	return z
+}
+
+// hash returns the key of the current position of the game.
+func (z *zobrist) hash(g *game.Game) uint64 {
+	var key uint64
+	b := g.Board()
+	for row := 0; row < z.dim; row++ {
+		for col := 0; col < z.dim; col++ {
+			ml := b.GetLetter(row, col)
+			if ml == alphabet.EmptySquareMarker {
+				continue
+			}
+			idx := int(ml)
+			if ml.IsBlanked() {
+				idx = int(ml-alphabet.BlankOffset) + alphabet.MaxAlphabetSize
+			}
+			key ^= z.boardKeys[row*z.dim+col][idx]
+		}
+	}
+	for p := range z.rackKeys {
+		for l, ct := range g.RackFor(p).LetArr {
+			if ct > 0 {
+				key ^= z.rackKeys[p][l][ct]
+			}
+		}
+	}
+	if g.PlayerOnTurn() == 1 {
+		key ^= z.sideToMoveKey
+	}
+	if g.Playing() == pb.PlayState_WAITING_FOR_FINAL_PASS {
+		key ^= z.waitingKey
+	}
+	if st := g.ScorelessTurns(); st < len(z.scorelessKeys) {
+		key ^= z.scorelessKeys[st]
+	}
+	return key
+}
+
+// moveHash identifies a move compactly, so that the best move of a
+// position can be kept in the transposition table and found again
+// among the plays generated for it.
+func moveHash(m *move.Move) uint64 {
+	h := fnv.New64a()
+	row, col, vertical := m.CoordsAndVertical()
+	var v byte
+	if vertical {
+		v = 1
+	}
+	h.Write([]byte{byte(m.Action()), byte(row), byte(col), v})
+	for _, t := range m.Tiles() {
+		h.Write([]byte{byte(t)})
+	}
+	return h.Sum64()
+}
+
+// prepareTable sets up an empty transposition table for a new search.
+func (s *Solver) prepareTable() {
+	if s.ttableMB <= 0 {
+		s.ttable = nil
+		return
+	}
+	if s.zobrist == nil || s.zobrist.dim != s.game.Board().Dim() {
+		s.zobrist = newZobrist(s.game.Board().Dim())
+	}
+	if s.ttable == nil {
+		s.ttable = newTranspositionTable(s.ttableMB)
+	} else {
+		s.ttable.reset()
+	}
+}
+
+type ttFlag uint8
+
+const (
+	ttExact ttFlag = iota
+	// ttLower means the value is a lower bound; the search failed high.
+	ttLower
+	// ttUpper means the value is an upper bound; the search failed low.
+	ttUpper
+)
+
+// ttEntry is what we know about a position. Node values depend on the
+// spread at the root and on how many turns were played to get to the
+// position, so the entry keeps them relative to the position instead:
+// the spread gained from here on, and the length of the rest of the
+// sequence.
+type ttEntry struct {
+	key       uint64
+	bestMove  uint64
+	gain      float32
+	depth     int8
+	flag      ttFlag
+	knownEnd  bool
+	seqLength int8
+}
+
+// transpositionTable is a fixed-size hash table of positions. When two
+// positions map to the same slot, the one searched deeper is kept.
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+
+	lookups int
+	hits    int
+}
+
+// newTranspositionTable creates a table that takes up at most the given
+// number of megabytes. It returns nil if that is too small to be useful.
+func newTranspositionTable(mb int) *transpositionTable {
+	n := uint64(mb) << 20 / uint64(unsafe.Sizeof(ttEntry{}))
+	if n == 0 {
+		return nil
+	}
+	// Round down to a power of two, so we can mask instead of mod.
+	size := uint64(1)
+	for size*2 <= n {
+		size *= 2
+	}
+	return &transpositionTable{
+		entries: make([]ttEntry, size),
+		mask:    size - 1,
+	}
+}
+
+func (t *transpositionTable) reset() {
+	for i := range t.entries {
+		t.entries[i] = ttEntry{}
+	}
+	t.lookups = 0
+	t.hits = 0
+}
+
+func (t *transpositionTable) lookup(key uint64) (*ttEntry, bool) {
+	t.lookups++
+	e := &t.entries[key&t.mask]
+	if e.key != key {
+		return nil, false
+	}
+	t.hits++
+	return e, true
+}
+
+func (t *transpositionTable) store(e ttEntry) {
+	old := &t.entries[e.key&t.mask]
+	if old.key == e.key && old.depth > e.depth {
+		return
+	}
+	*old = e
+}
@@ -1,51 +1,133 @@
 package alphabeta
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"strings"
 )
 
-// Attempt to visualize minimax graph with dot
+// TreeOptions limits the part of the search tree that is exported.
+type TreeOptions struct {
+	// MaxDepth is how many plies below the root are exported; 0 exports
+	// the whole tree.
+	MaxDepth int
+	// PVOnly only exports the principal variation, along with the
+	// siblings of every node in it.
+	PVOnly bool
+}
 
-type dotfile struct {
-	declarations []string
-	directives   []string
+// TreeNode is a node of the exported search tree. Values and bounds are
+// spreads, from the point of view of the player the endgame is solved for.
+type TreeNode struct {
+	// Move is empty for the root.
+	Move  string  `json:"move,omitempty"`
+	Value float32 `json:"value"`
+	// Alpha and Beta are the window the node was last searched with.
+	Alpha float32 `json:"alpha"`
+	Beta  float32 `json:"beta"`
+	// Cutoff is whether the search stopped before trying all of the
+	// node's moves.
+	Cutoff   bool        `json:"cutoff"`
+	KnownEnd bool        `json:"knownEnd"`
+	PV       bool        `json:"pv"`
+	Children []*TreeNode `json:"children,omitempty"`
 }
 
-func genDotFile(n *GameNode, d *dotfile) {
-	if len(n.children) == 0 {
-		// terminal node
-		// decl := fmt.Sprintf("%p [label=\"%v\nV: %v\"];",
-		// 	n, joinDesc(n.move.ShortDescription()), n.move.Valuation(),
-		// )
-		// declarations = append(declarations, decl)
-		return
+// SearchTree returns the tree of the last solve, or nil if there was none.
+// Nodes only searched in a depth that did not finish keep what they were
+// given then.
+func (s *Solver) SearchTree(opts TreeOptions) *TreeNode {
+	if s.rootNode == nil {
+		return nil
 	}
+	pv := map[*GameNode]bool{}
+	for n := s.pvNode; n != nil; n = n.parent {
+		pv[n] = true
+	}
+	return exportNode(s.rootNode, 0, opts, pv)
+}
 
-	parent := n
-	for _, child := range parent.children {
-		decl := fmt.Sprintf("n_%p [label=\"%v\\nPlayVal: %v\\nNodeVal: %v\"];",
-			child, child.move.ShortDescription(),
-			child.move.Valuation(), child.heuristicValue)
-
-		conn := fmt.Sprintf("n_%p -> n_%p;", parent, child)
-		d.declarations = append(d.declarations, decl)
-		d.directives = append(d.directives, conn)
-		genDotFile(child, d)
+func exportNode(n *GameNode, depth int, opts TreeOptions, pv map[*GameNode]bool) *TreeNode {
+	t := &TreeNode{
+		Value:    n.heuristicValue.value,
+		Alpha:    n.alpha,
+		Beta:     n.beta,
+		Cutoff:   n.cutoff,
+		KnownEnd: n.heuristicValue.knownEnd,
+		PV:       pv[n],
+	}
+	if n.move != nil {
+		t.Move = n.move.ShortDescription()
+	}
+	if opts.MaxDepth > 0 && depth == opts.MaxDepth {
+		return t
 	}
+	// With PVOnly, only the nodes in the PV are expanded, so their
+	// children are the PV and its siblings.
+	if opts.PVOnly && !t.PV {
+		return t
+	}
+	for _, c := range n.children {
+		t.Children = append(t.Children, exportNode(c, depth+1, opts, pv))
+	}
+	return t
+}
+
+// WriteTreeJSON writes the tree as indented JSON.
+func WriteTreeJSON(w io.Writer, root *TreeNode) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(root)
 }
 
-func saveDotFile(root *GameNode, d *dotfile, outFile string) {
-	out := ""
-	out += fmt.Sprintf("digraph {\n")
-	out += fmt.Sprintf(" n_%p [label=\"(root)\"]\n", root)
-	for _, d := range d.declarations {
-		out += fmt.Sprintf(" %v\n", d)
+// WriteTreeDot writes the tree as a Graphviz graph. The principal
+// variation is drawn in bold, and nodes that were cut off are dashed.
+func WriteTreeDot(w io.Writer, root *TreeNode) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph {")
+	fmt.Fprintln(bw, " node [shape=box];")
+	id := 0
+	var write func(t *TreeNode) int
+	write = func(t *TreeNode) int {
+		me := id
+		id++
+		label := t.Move
+		if label == "" {
+			label = "(root)"
+		}
+		var style []string
+		if t.PV {
+			style = append(style, "bold")
+		}
+		if t.Cutoff {
+			style = append(style, "dashed")
+		}
+		fmt.Fprintf(bw, " n%d [label=\"%v\\nvalue: %v\\nwindow: [%v, %v]\"",
+			me, label, t.Value, boundString(t.Alpha), boundString(t.Beta))
+		if len(style) > 0 {
+			fmt.Fprintf(bw, " style=\"%v\"", strings.Join(style, ","))
+		}
+		fmt.Fprintln(bw, "];")
+		for _, c := range t.Children {
+			child := write(c)
+			fmt.Fprintf(bw, " n%d -> n%d;\n", me, child)
+		}
+		return me
 	}
-	out += fmt.Sprintf("\n")
-	for _, d := range d.directives {
-		out += fmt.Sprintf(" %v\n", d)
+	write(root)
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// boundString shows a bound of a search window, with infinity as such.
+func boundString(b float32) string {
+	switch {
+	case b >= Infinity:
+		return "inf"
+	case b <= -Infinity:
+		return "-inf"
 	}
-	out += fmt.Sprint("}\n")
-	ioutil.WriteFile(outFile, []byte(out), 0644)
+	return fmt.Sprintf("%v", b)
 }
@@ -0,0 +1,146 @@
+// Package greedy implements a fast endgame solver that doesn't search. It
+// tries every move of the player on turn, and plays the rest of the game
+// out with both players making the move that gains them the most right
+// away; the first move of the best playout is the solution. It is much
+// weaker than the alphabeta solver, but it doesn't search a tree: its time
+// is only linear in the number of plies, one move generation per ply, which
+// makes it good enough for quick playouts.
+package greedy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/endgame"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/movegen"
+)
+
+// Solver is the greedy outplay solver.
+type Solver struct {
+	movegen movegen.MoveGenerator
+	game    *game.Game
+	nodes   int
+	stats   endgame.Stats
+}
+
+// NewSolver returns a new greedy solver, for callers that take an
+// endgame.NewSolverFunc.
+func NewSolver() endgame.Solver {
+	return &Solver{}
+}
+
+func (s *Solver) Init(movegen movegen.MoveGenerator, game *game.Game) error {
+	s.movegen = movegen
+	s.game = game
+	s.stats = endgame.Stats{}
+	return nil
+}
+
+// Stats returns the statistics of the last solve. Every move played in a
+// playout counts as a node.
+func (s *Solver) Stats() endgame.Stats {
+	return s.stats
+}
+
+// gain is how much the player on turn gains from the move right away: its
+// score, and twice the tiles on the opponent's rack if it goes out.
+func (s *Solver) gain(m *move.Move) int {
+	onTurn := s.game.PlayerOnTurn()
+	if m.Action() != move.MoveTypePlay {
+		return 0
+	}
+	g := m.Score()
+	if m.TilesPlayed() == int(s.game.RackFor(onTurn).NumTiles()) {
+		opp := s.game.RackFor((onTurn + 1) % s.game.NumPlayers())
+		g += 2 * opp.ScoreOn(s.game.Bag().LetterDistribution())
+	}
+	return g
+}
+
+// generate returns the moves of the player on turn, with the greediest
+// first.
+func (s *Solver) generate() []*move.Move {
+	s.movegen.GenAll(s.game.RackFor(s.game.PlayerOnTurn()), false)
+	plays := append([]*move.Move(nil), s.movegen.Plays()...)
+	gains := make(map[*move.Move]int, len(plays))
+	for _, p := range plays {
+		gains[p] = s.gain(p)
+	}
+	sort.SliceStable(plays, func(i, j int) bool {
+		return gains[plays[i]] > gains[plays[j]]
+	})
+	return plays
+}
+
+// playout plays the greediest move for whoever is on turn, until the game
+// is over or plies moves were played. It returns the moves; the caller
+// unplays them.
+func (s *Solver) playout(plies int) []*move.Move {
+	var seq []*move.Move
+	for len(seq) < plies && s.game.Playing() == pb.PlayState_PLAYING {
+		m := s.generate()[0]
+		s.game.PlayMove(m, false, 0)
+		s.nodes++
+		seq = append(seq, m)
+	}
+	return seq
+}
+
+// Solve plays out the game after every move of the player on turn, for at
+// most plies moves in all, and returns the spread gained by the best
+// playout.
+func (s *Solver) Solve(ctx context.Context, plies int) (float32, []*move.Move, error) {
+	s.stats = endgame.Stats{}
+	if s.game.Bag().TilesRemaining() > 0 {
+		return 0, nil, errors.New("bag is not empty; cannot use endgame solver")
+	}
+	if plies < 1 {
+		return 0, nil, errors.New("plies must be at least 1")
+	}
+	start := time.Now()
+	s.nodes = 0
+
+	// As with the alphabeta solver, there are no challenges in a playout,
+	// and the play state needs to be put back afterwards.
+	if h := s.game.History(); h != nil {
+		rule, state := h.ChallengeRule, h.PlayState
+		h.ChallengeRule = pb.ChallengeRule_VOID
+		defer func() {
+			h.ChallengeRule = rule
+			h.PlayState = state
+		}()
+	}
+
+	us := s.game.PlayerOnTurn()
+	them := (us + 1) % s.game.NumPlayers()
+	initialSpread := s.game.PointsFor(us) - s.game.PointsFor(them)
+
+	var bestV float32
+	var bestSeq []*move.Move
+	for _, m := range s.generate() {
+		if ctx.Err() != nil {
+			return 0, nil, ctx.Err()
+		}
+		s.game.PlayMove(m, false, 0)
+		s.nodes++
+		seq := append([]*move.Move{m}, s.playout(plies-1)...)
+		v := float32(s.game.PointsFor(us) - s.game.PointsFor(them) - initialSpread)
+		for range seq {
+			s.game.UnplayLastMove()
+		}
+		// On a tie, keep the greedier move.
+		if bestSeq == nil || v > bestV {
+			bestV, bestSeq = v, seq
+		}
+	}
+	s.stats = endgame.Stats{Nodes: s.nodes, Depth: plies, Elapsed: time.Since(start)}
+	log.Debug().Int("nodes", s.nodes).Float32("value", bestV).Msg("greedy-endgame")
+	return bestV, bestSeq, nil
+}
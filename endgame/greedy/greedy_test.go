@@ -0,0 +1,98 @@
+package greedy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/endgame"
+	"github.com/domino14/macondo/endgame/alphabeta"
+	"github.com/domino14/macondo/gaddagmaker"
+	"github.com/domino14/macondo/game"
+	"github.com/domino14/macondo/gcgio"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/runner"
+)
+
+var DefaultConfig = config.DefaultConfig()
+
+func TestMain(m *testing.M) {
+	for _, lex := range []string{"NWL18"} {
+		gdgPath := filepath.Join(DefaultConfig.LexiconPath, "gaddag", lex+".gaddag")
+		if _, err := os.Stat(gdgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateGaddag(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.Rename("out.gaddag", gdgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func setUpEndgame(is *is.I, plies int) *runner.AIGameRunner {
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../../gcgio/testdata/doug_v_emely.gcg")
+	is.NoErr(err)
+	boardLayout, ldName := game.HistoryToVariant(history)
+	rules, err := runner.NewAIGameRules(&DefaultConfig, boardLayout, history.Variant, "NWL18", ldName)
+	is.NoErr(err)
+	g, err := game.NewFromHistory(history, rules, 0)
+	is.NoErr(err)
+	gr, err := runner.NewAIGameRunnerFromGame(g, &DefaultConfig)
+	is.NoErr(err)
+	gr.SetChallengeRule(pb.ChallengeRule_DOUBLE)
+	// The bag is empty on this turn.
+	is.NoErr(gr.PlayToTurn(25))
+	is.Equal(gr.Bag().TilesRemaining(), 0)
+	gr.SetStateStackLength(plies)
+	gr.SetBackupMode(game.SimulationMode)
+	return gr
+}
+
+func TestSolve(t *testing.T) {
+	is := is.New(t)
+	plies := 3
+	gr := setUpEndgame(is, plies)
+	spread := gr.CurrentSpread()
+
+	s := &Solver{}
+	is.NoErr(s.Init(gr.MoveGenerator(), &gr.Game))
+	v, seq, err := s.Solve(context.Background(), plies)
+	is.NoErr(err)
+	is.True(len(seq) > 0)
+	is.True(len(seq) <= plies)
+	is.True(s.Stats().Nodes >= len(seq))
+	is.Equal(s.Stats().Depth, plies)
+
+	// The game is left alone.
+	is.Equal(gr.Turn(), 25)
+	is.Equal(gr.CurrentSpread(), spread)
+
+	// The value is what the sequence gains. Going out ends the game right
+	// away in the solver, without waiting for a challenge.
+	gr.SetChallengeRule(pb.ChallengeRule_VOID)
+	onTurn := gr.PlayerOnTurn()
+	for _, m := range seq {
+		is.NoErr(gr.PlayMove(m, false, 0))
+	}
+	is.Equal(v, float32(gr.SpreadFor(onTurn)-spread))
+}
+
+func TestSolversAreInterchangeable(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+	for _, newSolver := range []endgame.NewSolverFunc{NewSolver, alphabeta.NewSolver} {
+		gr := setUpEndgame(is, plies)
+		s := newSolver()
+		is.NoErr(s.Init(gr.MoveGenerator(), &gr.Game))
+		_, seq, err := s.Solve(context.Background(), plies)
+		is.NoErr(err)
+		is.True(len(seq) > 0)
+		is.True(s.Stats().Nodes > 0)
+		is.Equal(s.Stats().Depth, plies)
+	}
+}
@@ -1,6 +1,9 @@
 package endgame
 
 import (
+	"context"
+	"time"
+
 	"github.com/domino14/macondo/game"
 	"github.com/domino14/macondo/move"
 	"github.com/domino14/macondo/movegen"
@@ -9,6 +12,28 @@ import (
 // Solver is an interface for an endgame solver. The scores don't matter, the
 // solver maximizes spread.
 type Solver interface {
-	Init(movegen movegen.MoveGenerator, game *game.Game)
-	Solve(plies int) (float32, *move.Move)
+	// Init sets up the solver for the game. The game must be in simulation
+	// backup mode, with room to back up every ply the solver plays.
+	Init(movegen movegen.MoveGenerator, game *game.Game) error
+	// Solve returns the spread the player on turn gains from here, looking
+	// at most plies moves ahead, and the sequence of moves that gets it.
+	// The game is left as it was.
+	Solve(ctx context.Context, plies int) (float32, []*move.Move, error)
+	// Stats returns the statistics of the last solve.
+	Stats() Stats
+}
+
+// Stats are the statistics of a solve.
+type Stats struct {
+	// Nodes is the number of positions the solver looked at.
+	Nodes int
+	// Depth is how many plies the solver looked ahead; for a search that
+	// deepens one ply at a time, the deepest one that finished.
+	Depth   int
+	Elapsed time.Duration
 }
+
+// NewSolverFunc makes a new solver, not initialized yet. Callers that need
+// several solvers, such as one for every thread, take one of these, so
+// that they can use any kind of solver.
+type NewSolverFunc func() Solver
@@ -0,0 +1,89 @@
+package gaddag
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+// MmapGaddag loads a gaddag that was saved with SaveAligned (see
+// `make_gaddag -mmap`) by memory-mapping its file, and uses the nodes and
+// letter sets of the file where they are, without reading or copying them.
+// Processes that load the same file share its pages, and loading is nearly
+// instant. The file stays mapped for as long as the process runs, as
+// gaddags do, so it must be replaced by renaming a new file over it; writing
+// over it in place crashes the processes using it with SIGBUS. Gaddags in
+// the older format are loaded by LoadGaddag instead.
+func MmapGaddag(filename string) (*SimpleGaddag, error) {
+	log.Debug().Msgf("Mapping %v ...", filename)
+	bts, err := mmapFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(bts) < 4 || string(bts[:4]) != gaddagmaker.AlignedGaddagMagicNumber {
+		munmapFile(bts)
+		return LoadGaddag(filename)
+	}
+	gd, err := alignedGaddag(bts)
+	if err != nil {
+		munmapFile(bts)
+		return nil, err
+	}
+	return gd, nil
+}
+
+// alignedGaddag makes a gaddag out of the bytes of a file saved with
+// SaveAligned. On little-endian machines, its nodes and letter sets are the
+// ones in the bytes.
+func alignedGaddag(bts []byte) (*SimpleGaddag, error) {
+	errTooShort := errors.New("aligned gaddag is too short")
+	if len(bts) < 5 || string(bts[:4]) != gaddagmaker.AlignedGaddagMagicNumber {
+		return nil, errors.New("magic number does not match aligned gaddag")
+	}
+	lexNameLen := int(bts[4])
+	pos := alignTo(5+lexNameLen, 4)
+	if pos+4 > len(bts) {
+		return nil, errTooShort
+	}
+	lexName := string(bts[5 : 5+lexNameLen])
+
+	alphabetSize := int(binary.LittleEndian.Uint32(bts[pos:]))
+	pos += 4
+	if pos+4*alphabetSize+4 > len(bts) {
+		return nil, errTooShort
+	}
+	alphabetArr := make([]uint32, alphabetSize)
+	for i := range alphabetArr {
+		alphabetArr[i] = binary.LittleEndian.Uint32(bts[pos+4*i:])
+	}
+	pos += 4 * alphabetSize
+
+	lettersetSize := int(binary.LittleEndian.Uint32(bts[pos:]))
+	pos = alignTo(pos+4, 8)
+	if pos+8*lettersetSize+4 > len(bts) {
+		return nil, errTooShort
+	}
+	letterSets := letterSetsFromBytes(bts[pos : pos+8*lettersetSize])
+	pos += 8 * lettersetSize
+
+	nodeSize := int(binary.LittleEndian.Uint32(bts[pos:]))
+	pos += 4
+	if pos+4*nodeSize > len(bts) {
+		return nil, errTooShort
+	}
+	nodes := uint32sFromBytes(bts[pos : pos+4*nodeSize])
+	log.Debug().Msgf("Aligned gaddag %v: %v letter sets, %v nodes", lexName,
+		lettersetSize, nodeSize)
+
+	return &SimpleGaddag{nodes: nodes, letterSets: letterSets,
+		alphabet:    alphabet.FromSlice(alphabetArr),
+		lexiconName: lexName}, nil
+}
+
+func alignTo(pos, n int) int {
+	return (pos + n - 1) / n * n
+}
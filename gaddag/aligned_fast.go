@@ -0,0 +1,28 @@
+//go:build 386 || amd64 || arm || arm64 || ppc64le || riscv64
+// +build 386 amd64 arm arm64 ppc64le riscv64
+
+package gaddag
+
+import (
+	"github.com/alecthomas/unsafeslice"
+
+	"github.com/domino14/macondo/alphabet"
+)
+
+// On little-endian machines, the arrays of an aligned gaddag are used
+// where they are, without copying them.
+
+func uint32sFromBytes(b []byte) []uint32 {
+	if len(b) == 0 {
+		return nil
+	}
+	return unsafeslice.Uint32SliceFromByteSlice(b)
+}
+
+func letterSetsFromBytes(b []byte) []alphabet.LetterSet {
+	var letterSets []alphabet.LetterSet
+	if len(b) > 0 {
+		unsafeslice.StructSliceFromByteSlice(b, &letterSets)
+	}
+	return letterSets
+}
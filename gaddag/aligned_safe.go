@@ -0,0 +1,28 @@
+//go:build !386 && !amd64 && !arm && !arm64 && !ppc64le && !riscv64
+// +build !386,!amd64,!arm,!arm64,!ppc64le,!riscv64
+
+package gaddag
+
+import (
+	"encoding/binary"
+
+	"github.com/domino14/macondo/alphabet"
+)
+
+// On other machines, the arrays of an aligned gaddag are copied.
+
+func uint32sFromBytes(b []byte) []uint32 {
+	u := make([]uint32, len(b)/4)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint32(b[4*i:])
+	}
+	return u
+}
+
+func letterSetsFromBytes(b []byte) []alphabet.LetterSet {
+	letterSets := make([]alphabet.LetterSet, len(b)/8)
+	for i := range letterSets {
+		letterSets[i] = alphabet.LetterSet(binary.LittleEndian.Uint64(b[8*i:]))
+	}
+	return letterSets
+}
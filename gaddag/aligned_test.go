@@ -0,0 +1,71 @@
+package gaddag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+func TestMmapGaddag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gaddag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, wordList := range []string{
+		filepath.Join(DefaultConfig.LexiconPath, "America.txt"),
+		"../gaddagmaker/test_files/little_spanish.txt",
+	} {
+		gd := gaddagmaker.GenerateGaddag(wordList, true, false)
+		expected := GaddagToSimpleGaddag(gd)
+		aligned := filepath.Join(dir, "aligned.gaddag")
+		gd.SaveAligned(aligned)
+		older := filepath.Join(dir, "older.gaddag")
+		gd.Save(older, gaddagmaker.GaddagMagicNumber)
+
+		// Both formats load the same, mapped or not.
+		for _, filename := range []string{aligned, older} {
+			mapped, err := MmapGaddag(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded, err := LoadGaddag(filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, g := range []*SimpleGaddag{mapped, loaded} {
+				if !reflect.DeepEqual(g.nodes, expected.nodes) ||
+					!reflect.DeepEqual(g.letterSets, expected.letterSets) ||
+					!reflect.DeepEqual(g.alphabet, expected.alphabet) ||
+					g.lexiconName != expected.lexiconName {
+					t.Errorf("%v did not load the same as the gaddag of %v", filename, wordList)
+				}
+			}
+		}
+	}
+}
+
+func TestMmapGaddagTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gaddag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	gd := gaddagmaker.GenerateGaddag("../gaddagmaker/test_files/little_spanish.txt", true, false)
+	filename := filepath.Join(dir, "aligned.gaddag")
+	gd.SaveAligned(filename)
+	bts, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filename, bts[:len(bts)-4], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = MmapGaddag(filename); err == nil {
+		t.Error("expected an error for a truncated gaddag")
+	}
+}
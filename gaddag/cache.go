@@ -1,14 +1,39 @@
 package gaddag
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/domino14/macondo/alphabet"
 	"github.com/domino14/macondo/config"
 )
 
 // CacheLoadFunc is the function that loads a gaddag object into the global cache.
+// Gaddags in the aligned format are memory-mapped; see MmapGaddag.
+// If there is no gaddag file for the lexicon, but there is a KWG file in
+// the kwg directory, the gaddag is loaded from that.
 func CacheLoadFunc(cfg *config.Config, key string) (interface{}, error) {
 	lexiconName := strings.TrimPrefix(key, "gaddag:")
-	return LoadGaddag(filepath.Join(cfg.LexiconPath, "gaddag", lexiconName+".gaddag"))
+	gaddagPath := filepath.Join(cfg.LexiconPath, "gaddag", lexiconName+".gaddag")
+	kwgPath := filepath.Join(cfg.LexiconPath, "kwg", lexiconName+".kwg")
+	if _, err := os.Stat(gaddagPath); os.IsNotExist(err) {
+		if _, err := os.Stat(kwgPath); err == nil {
+			ld, err := alphabet.NamedLetterDistribution(cfg,
+				alphabet.DistributionNameForLexicon(lexiconName))
+			if err != nil {
+				return nil, err
+			}
+			return LoadKWG(kwgPath, ld)
+		}
+	}
+	return MmapGaddag(gaddagPath)
+}
+
+// AlphagramCacheLoadFunc loads the alphagram dawg of a lexicon, for
+// WordSmog, into the global cache. The key is "alphagrams:" and the name
+// of the lexicon.
+func AlphagramCacheLoadFunc(cfg *config.Config, key string) (interface{}, error) {
+	lexiconName := strings.TrimPrefix(key, "alphagrams:")
+	return LoadDawg(filepath.Join(cfg.LexiconPath, "alphagrams", lexiconName+".dawg"))
 }
@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"errors"
+	"io/ioutil"
 	"os"
 
 	"github.com/rs/zerolog/log"
@@ -64,6 +65,8 @@ func GaddagToSimpleGaddag(g *gaddagmaker.Gaddag) *SimpleGaddag {
 }
 
 // LoadGaddag loads a gaddag from a file and returns a *SimpleGaddag structure.
+// The file can also be in the aligned format of SaveAligned, which is read
+// whole.
 func LoadGaddag(filename string) (*SimpleGaddag, error) {
 	log.Debug().Msgf("Loading %v ...", filename)
 	file, err := os.Open(filename)
@@ -74,6 +77,14 @@ func LoadGaddag(filename string) (*SimpleGaddag, error) {
 	var magicStr [4]uint8
 	binary.Read(file, binary.BigEndian, &magicStr)
 
+	if string(magicStr[:]) == gaddagmaker.AlignedGaddagMagicNumber {
+		bts, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		return alignedGaddag(bts)
+	}
+
 	if !compareMagicGaddag(magicStr) {
 		return nil, errors.New("magic number does not match gaddag")
 	}
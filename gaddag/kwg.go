@@ -0,0 +1,169 @@
+package gaddag
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+// A KWG file, the lexicon format of other engines, has both a dawg and a
+// gaddag; see gaddagmaker.GenerateKWG for its layout. The loaders below
+// turn the lists of siblings of one of them into the nodes and arcs of a
+// SimpleGaddag, so that it is as fast to use as one loaded from our own
+// files.
+
+// ReadKWG reads the nodes of a KWG from a file.
+func ReadKWG(filename string) ([]uint32, error) {
+	bts, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if len(bts) < 8 || len(bts)%4 != 0 {
+		return nil, errors.New("file is not a kwg: " + filename)
+	}
+	kwg := make([]uint32, len(bts)/4)
+	for i := range kwg {
+		kwg[i] = binary.LittleEndian.Uint32(bts[4*i:])
+	}
+	return kwg, nil
+}
+
+// LoadKWG loads the gaddag of a KWG file. The tiles of the KWG are the
+// letters of the letter distribution, in order.
+func LoadKWG(filename string, ld *alphabet.LetterDistribution) (*SimpleGaddag, error) {
+	log.Debug().Msgf("Loading %v ...", filename)
+	kwg, err := ReadKWG(filename)
+	if err != nil {
+		return nil, err
+	}
+	return kwgToSimpleGaddag(kwg, 1, lexiconNameFromFile(filename), ld)
+}
+
+// LoadKWGDawg loads the dawg of a KWG file. The tiles of the KWG are the
+// letters of the letter distribution, in order.
+func LoadKWGDawg(filename string, ld *alphabet.LetterDistribution) (*SimpleDawg, error) {
+	log.Debug().Msgf("Loading %v ...", filename)
+	kwg, err := ReadKWG(filename)
+	if err != nil {
+		return nil, err
+	}
+	g, err := kwgToSimpleGaddag(kwg, 0, lexiconNameFromFile(filename), ld)
+	if err != nil {
+		return nil, err
+	}
+	return &SimpleDawg{SimpleGaddag: *g}, nil
+}
+
+func lexiconNameFromFile(filename string) string {
+	return strings.Split(filepath.Base(filename), ".")[0]
+}
+
+// kwgSiblings returns the list of siblings that starts at the node p.
+func kwgSiblings(kwg []uint32, p uint32) ([]uint32, error) {
+	for end := p; int(end) < len(kwg); end++ {
+		if kwg[end]&gaddagmaker.KWGIsEndBit != 0 {
+			return kwg[p : end+1], nil
+		}
+	}
+	return nil, fmt.Errorf("kwg list at %v does not end", p)
+}
+
+// kwgToSimpleGaddag makes a SimpleGaddag out of the graph whose root list
+// is pointed to by the node of the KWG at rootPtr: 0 for the dawg and 1 for
+// the gaddag. Every list that can be reached becomes a node, with the
+// letters of its accepting entries as its letter set, and an arc for every
+// entry with children. The root is node 0, as in our own files.
+func kwgToSimpleGaddag(kwg []uint32, rootPtr int, lexiconName string,
+	ld *alphabet.LetterDistribution) (*SimpleGaddag, error) {
+
+	// A serialized alphabet starts with its size.
+	alph := alphabet.FromSlice(ld.Alphabet().Serialize()[1:])
+	letters := ld.TileLetters()
+	mls := make([]alphabet.MachineLetter, len(letters)+1)
+	mls[0] = alphabet.SeparationMachineLetter
+	for i, letter := range letters {
+		ml, err := alph.Val(letter)
+		if err != nil {
+			return nil, err
+		}
+		mls[i+1] = ml
+	}
+
+	root := kwg[rootPtr] & gaddagmaker.KWGArcIdxBitMask
+	if root == 0 {
+		return nil, errors.New("the kwg does not have this graph")
+	}
+	// Find all the lists, and the index of the node each of them will be.
+	lists := [][]uint32{}
+	nodeIdxs := map[uint32]uint32{root: 0}
+	numNodes := uint32(0)
+	queue := []uint32{root}
+	for i := 0; i < len(queue); i++ {
+		list, err := kwgSiblings(kwg, queue[i])
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, list)
+		nodeIdxs[queue[i]] = numNodes
+		numNodes++
+		for _, n := range list {
+			next := n & gaddagmaker.KWGArcIdxBitMask
+			if next == 0 {
+				continue
+			}
+			numNodes++
+			if _, ok := nodeIdxs[next]; !ok {
+				nodeIdxs[next] = 0
+				queue = append(queue, next)
+			}
+		}
+	}
+	if numNodes > gaddagmaker.NodeIdxBitMask {
+		return nil, errors.New("the kwg is too big")
+	}
+
+	nodes := make([]uint32, 0, numNodes)
+	letterSets := []alphabet.LetterSet{}
+	letterSetIdxs := map[alphabet.LetterSet]uint32{}
+	for _, list := range lists {
+		var letterSet alphabet.LetterSet
+		arcs := []uint32{}
+		for _, n := range list {
+			tile := n >> gaddagmaker.KWGTileBitLoc
+			if int(tile) >= len(mls) {
+				return nil, fmt.Errorf("tile %v is not in the letter distribution", tile)
+			}
+			ml := mls[tile]
+			if n&gaddagmaker.KWGAcceptsBit != 0 && tile != 0 {
+				letterSet |= 1 << ml
+			}
+			if next := n & gaddagmaker.KWGArcIdxBitMask; next != 0 {
+				arcs = append(arcs, uint32(ml)<<gaddagmaker.LetterBitLoc+nodeIdxs[next])
+			}
+		}
+		// Arcs are sorted by letter; see NextNodeIdx.
+		sort.Slice(arcs, func(i, j int) bool { return arcs[i] < arcs[j] })
+		letterSetIdx, ok := letterSetIdxs[letterSet]
+		if !ok {
+			letterSetIdx = uint32(len(letterSets))
+			letterSetIdxs[letterSet] = letterSetIdx
+			letterSets = append(letterSets, letterSet)
+		}
+		nodes = append(nodes, letterSetIdx+uint32(len(arcs))<<gaddagmaker.NumArcsBitLoc)
+		nodes = append(nodes, arcs...)
+	}
+	log.Debug().Msgf("Converted kwg to %v nodes and %v letter sets", len(nodes),
+		len(letterSets))
+
+	return &SimpleGaddag{nodes: nodes, letterSets: letterSets, alphabet: alph,
+		lexiconName: lexiconName}, nil
+}
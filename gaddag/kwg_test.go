@@ -0,0 +1,111 @@
+package gaddag
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+func makeKWG(t *testing.T, wordList string, ld *alphabet.LetterDistribution) string {
+	dir, err := ioutil.TempDir("", "kwg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	nodes := gaddagmaker.GenerateKWG(wordList, true, false, ld.TileLetters())
+	filename := filepath.Join(dir, lexiconNameFromFile(wordList)+".kwg")
+	gaddagmaker.SaveKWG(filename, nodes)
+	return filename
+}
+
+func TestLoadKWG(t *testing.T) {
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wordList := filepath.Join(DefaultConfig.LexiconPath, "America.txt")
+	filename := makeKWG(t, wordList, ld)
+	gd, err := LoadKWG(filename, ld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dawg, err := LoadKWGDawg(filename, ld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ml, err := gd.GetAlphabet().Val('A'); err != nil || ml != 0 {
+		t.Errorf("A was %v, %v", ml, err)
+	}
+	if gd.LexiconName() != "America" || dawg.LexiconName() != "America" {
+		t.Errorf("lexicon names were %v and %v", gd.LexiconName(), dawg.LexiconName())
+	}
+
+	// Every word is found, and every word with a letter added is found
+	// only if it is in the word list too.
+	file, err := os.Open(wordList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	words := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		words[scanner.Text()] = true
+	}
+	alph := gd.GetAlphabet()
+	for word := range words {
+		mw, err := alphabet.ToMachineWord(word, alph)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !FindMachineWord(gd, mw) || !FindMachineWord(dawg, mw) {
+			t.Fatal("did not find", word)
+		}
+		for ml := alphabet.MachineLetter(0); ml < alphabet.MachineLetter(alph.NumLetters()); ml++ {
+			longer := append(mw[:len(mw):len(mw)], ml)
+			found := words[longer.UserVisible(alph)]
+			if FindMachineWord(gd, longer) != found || FindMachineWord(dawg, longer) != found {
+				t.Fatal("expected", found, "for", longer.UserVisible(alph))
+			}
+		}
+	}
+}
+
+func TestLoadKWGSpanish(t *testing.T) {
+	ld, err := alphabet.SpanishLetterDistribution(&DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The digraphs are tiles right after their first letters, and not in
+	// the order of the alphabet.
+	filename := makeKWG(t, "../gaddagmaker/test_files/little_spanish.txt", ld)
+	gd, err := LoadKWG(filename, ld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dawg, err := LoadKWGDawg(filename, ld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range []testpair{
+		{"AÑO", true},
+		{"COMIDAS", true},
+		{"CO3AL", true},
+		{"CO3A", false},
+		{"COMIDA3", false},
+		{"AÑOS", false},
+	} {
+		mw, err := alphabet.ToMachineWord(pair.prefix, gd.GetAlphabet())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if FindMachineWord(gd, mw) != pair.found || FindMachineWord(dawg, mw) != pair.found {
+			t.Error("For", pair.prefix, "expected", pair.found)
+		}
+	}
+}
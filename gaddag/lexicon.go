@@ -1,6 +1,8 @@
 package gaddag
 
 import (
+	"sort"
+
 	"github.com/domino14/macondo/alphabet"
 )
 
@@ -15,3 +17,32 @@ func (l Lexicon) Name() string {
 func (l Lexicon) HasWord(word alphabet.MachineWord) bool {
 	return FindMachineWord(l, word)
 }
+
+// AlphagramLexicon is the lexicon of WordSmog, where a word is good if any
+// of its anagrams is. Its dawg has the alphagram of every word, made with
+// gaddagmaker.GenerateAlphagramDawg.
+type AlphagramLexicon struct {
+	GenericDawg
+}
+
+func (l AlphagramLexicon) Name() string {
+	return l.LexiconName()
+}
+
+func (l AlphagramLexicon) HasWord(word alphabet.MachineWord) bool {
+	if len(word) < 2 {
+		return false
+	}
+	return FindMachineWord(l, Alphagram(word))
+}
+
+// Alphagram returns the letters of the word in alphabetical order, with
+// blanks turned into the letters they stand for.
+func Alphagram(word alphabet.MachineWord) alphabet.MachineWord {
+	alphagram := make(alphabet.MachineWord, len(word))
+	for i, ml := range word {
+		alphagram[i] = ml.Unblank()
+	}
+	sort.Slice(alphagram, func(i, j int) bool { return alphagram[i] < alphagram[j] })
+	return alphagram
+}
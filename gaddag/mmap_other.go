@@ -0,0 +1,17 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package gaddag
+
+import (
+	"io/ioutil"
+)
+
+// mmapFile reads the whole file, on systems where it isn't mapped.
+func mmapFile(filename string) ([]byte, error) {
+	return ioutil.ReadFile(filename)
+}
+
+func munmapFile(b []byte) error {
+	return nil
+}
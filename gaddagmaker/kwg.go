@@ -0,0 +1,276 @@
+// Here we have utility functions for writing KWGs and KLVs, the lexicon
+// and leave formats of other engines.
+package gaddagmaker
+
+import (
+	"bufio"
+	"encoding/binary"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/alphabet"
+)
+
+// A KWG is an array of little-endian 32-bit nodes. Every node is an entry
+// in a list of siblings: the index of the list of its children is in the
+// lowest 22 bits, then there's a bit that is set on the last sibling of the
+// list, a bit that is set if the path to the entry is a word, and the tile
+// in the highest 8 bits. Tile 0 is the separation token of the gaddag (and
+// the blank, in a KLV), and the letters are 1 and up, in the order of the
+// letter distribution. The list of the dawg's root is pointed to by node 0,
+// and the list of the gaddag's root by node 1.
+const (
+	KWGArcIdxBitMask = (1 << 22) - 1
+	KWGIsEndBit      = 1 << 22
+	KWGAcceptsBit    = 1 << 23
+	KWGTileBitLoc    = 24
+)
+
+type kwgEntry struct {
+	tile    uint8
+	accepts bool
+	next    *kwgList
+}
+
+// kwgList is a temporary list of siblings, sorted by tile.
+type kwgList struct {
+	entries []kwgEntry
+}
+
+// entry returns the entry of the list for the tile, adding it if it isn't
+// there yet.
+func (l *kwgList) entry(tile uint8) *kwgEntry {
+	i := sort.Search(len(l.entries), func(i int) bool { return l.entries[i].tile >= tile })
+	if i == len(l.entries) || l.entries[i].tile != tile {
+		l.entries = append(l.entries, kwgEntry{})
+		copy(l.entries[i+1:], l.entries[i:])
+		l.entries[i] = kwgEntry{tile: tile}
+	}
+	return &l.entries[i]
+}
+
+// kwgTiles numbers the tiles of a KWG, given the letters of the
+// distribution in order.
+func kwgTiles(letters []rune) map[rune]uint8 {
+	tiles := map[rune]uint8{alphabet.SeparationToken: 0, alphabet.BlankToken: 0}
+	for i, letter := range letters {
+		tiles[letter] = uint8(i + 1)
+	}
+	return tiles
+}
+
+func kwgTile(tiles map[rune]uint8, letter rune) uint8 {
+	tile, ok := tiles[letter]
+	if !ok {
+		panic("letter " + string(letter) + " is not in the letter distribution")
+	}
+	return tile
+}
+
+// kwgListsFromNode turns the graph under the node into lists of siblings.
+func kwgListsFromNode(node *Node, alph *alphabet.Alphabet, tiles map[rune]uint8,
+	lists map[*Node]*kwgList) *kwgList {
+
+	if l, ok := lists[node]; ok {
+		return l
+	}
+	l := &kwgList{}
+	lists[node] = l
+	for ml := alphabet.MachineLetter(0); ml < alphabet.MachineLetter(alph.NumLetters()); ml++ {
+		if node.letterSet&(1<<ml) != 0 {
+			l.entry(kwgTile(tiles, alph.Letter(ml))).accepts = true
+		}
+	}
+	for _, arc := range node.arcs {
+		next := kwgListsFromNode(arc.destination, alph, tiles, lists)
+		l.entry(kwgTile(tiles, arc.letter)).next = next
+	}
+	return l
+}
+
+// kwgWriter lays out lists of siblings as KWG nodes. Lists with the same
+// nodes are only written once.
+type kwgWriter struct {
+	nodes     []uint32
+	indices   map[*kwgList]uint32
+	byContent map[string]uint32
+}
+
+func (w *kwgWriter) write(l *kwgList) uint32 {
+	if l == nil || len(l.entries) == 0 {
+		return 0
+	}
+	if idx, ok := w.indices[l]; ok {
+		return idx
+	}
+	list := make([]uint32, len(l.entries))
+	for i, e := range l.entries {
+		list[i] = uint32(e.tile)<<KWGTileBitLoc | w.write(e.next)
+		if e.accepts {
+			list[i] |= KWGAcceptsBit
+		}
+		if i == len(list)-1 {
+			list[i] |= KWGIsEndBit
+		}
+	}
+	key := make([]byte, 4*len(list))
+	for i, n := range list {
+		binary.LittleEndian.PutUint32(key[4*i:], n)
+	}
+	idx, ok := w.byContent[string(key)]
+	if !ok {
+		idx = uint32(len(w.nodes))
+		if len(w.nodes)+len(list) > KWGArcIdxBitMask {
+			panic("too many nodes for a KWG")
+		}
+		w.nodes = append(w.nodes, list...)
+		w.byContent[string(key)] = idx
+	}
+	w.indices[l] = idx
+	return idx
+}
+
+// serializeKWG lays out the lists of the dawg and of the gaddag, either of
+// which can be nil, as the nodes of a KWG.
+func serializeKWG(dawg, gaddag *kwgList) []uint32 {
+	w := &kwgWriter{
+		nodes:     []uint32{0, 0},
+		indices:   make(map[*kwgList]uint32),
+		byContent: make(map[string]uint32),
+	}
+	dawgIdx := w.write(dawg)
+	gaddagIdx := w.write(gaddag)
+	w.nodes[0] = dawgIdx | KWGIsEndBit
+	w.nodes[1] = gaddagIdx | KWGIsEndBit
+	return w.nodes
+}
+
+// GenerateKWG makes a KWG with the DAWG and the GADDAG of the words in the
+// file, and optionally minimizes them and/or writes the KWG to out.kwg.
+// The tiles are numbered in the order of letters, which should be the
+// letters of the lexicon's letter distribution; see
+// alphabet.LetterDistribution.TileLetters.
+func GenerateKWG(filename string, minimize bool, writeToFile bool, letters []rune) []uint32 {
+	dawg := GenerateDawg(filename, minimize, false, false)
+	if dawg.Root == nil {
+		return nil
+	}
+	gaddag := GenerateGaddag(filename, minimize, false)
+	tiles := kwgTiles(letters)
+	nodes := serializeKWG(
+		kwgListsFromNode(dawg.Root, dawg.Alphabet, tiles, make(map[*Node]*kwgList)),
+		kwgListsFromNode(gaddag.Root, gaddag.Alphabet, tiles, make(map[*Node]*kwgList)))
+	log.Info().Msgf("Made a KWG with %v nodes", len(nodes))
+	if writeToFile {
+		SaveKWG("out.kwg", nodes)
+	}
+	return nodes
+}
+
+// SaveKWG saves the nodes of a KWG to a file.
+func SaveKWG(filename string, nodes []uint32) {
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create file")
+	}
+	binary.Write(file, binary.LittleEndian, nodes)
+	file.Close()
+	log.Info().Msgf("Saved KWG to %v", filename)
+}
+
+// GenerateKLV makes a KLV out of a file with a leave and its value on every
+// line, separated by a comma, like `?ERS,36.5`. A KLV has a KWG with a DAWG
+// of the leaves, with their tiles sorted and the blank as tile 0, and the
+// values of the leaves in the order the DAWG has them. It optionally
+// writes the KLV to out.klv2, with the values as 32-bit floats.
+func GenerateKLV(filename string, writeToFile bool, letters []rune) ([]uint32, []float32) {
+	file, err := os.Open(filename)
+	if err != nil {
+		log.Warn().Msgf("Filename %v not found", filename)
+		return nil, nil
+	}
+	defer file.Close()
+
+	tiles := kwgTiles(letters)
+	root := &kwgList{}
+	values := make(map[string]float32)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 2 {
+			panic("bad leave line: " + line)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 32)
+		if err != nil {
+			panic(err)
+		}
+		leave := []uint8{}
+		for _, letter := range strings.ToUpper(strings.TrimSpace(fields[0])) {
+			leave = append(leave, kwgTile(tiles, letter))
+		}
+		if len(leave) == 0 {
+			continue
+		}
+		sort.Slice(leave, func(i, j int) bool { return leave[i] < leave[j] })
+		l := root
+		for i, tile := range leave {
+			e := l.entry(tile)
+			if i == len(leave)-1 {
+				e.accepts = true
+				break
+			}
+			if e.next == nil {
+				e.next = &kwgList{}
+			}
+			l = e.next
+		}
+		values[string(leave)] = float32(value)
+	}
+	log.Info().Msgf("Read %v leaves", len(values))
+
+	// The values go in the order of the DAWG: a leave comes before the
+	// leaves that start with it, and siblings are in the order of tiles.
+	ordered := make([]float32, 0, len(values))
+	var addValues func(l *kwgList, leave []uint8)
+	addValues = func(l *kwgList, leave []uint8) {
+		for _, e := range l.entries {
+			extended := append(leave, e.tile)
+			if e.accepts {
+				ordered = append(ordered, values[string(extended)])
+			}
+			if e.next != nil {
+				addValues(e.next, extended)
+			}
+		}
+	}
+	addValues(root, nil)
+
+	nodes := serializeKWG(root, nil)
+	if writeToFile {
+		SaveKLV("out.klv2", nodes, ordered)
+	}
+	return nodes, ordered
+}
+
+// SaveKLV saves a KLV to a file: the number of nodes of its KWG and the
+// nodes, then the number of values and the values, all little-endian.
+func SaveKLV(filename string, nodes []uint32, values []float32) {
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create file")
+	}
+	binary.Write(file, binary.LittleEndian, uint32(len(nodes)))
+	binary.Write(file, binary.LittleEndian, nodes)
+	binary.Write(file, binary.LittleEndian, uint32(len(values)))
+	binary.Write(file, binary.LittleEndian, values)
+	file.Close()
+	log.Info().Msgf("Saved KLV to %v", filename)
+}
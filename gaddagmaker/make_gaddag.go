@@ -20,6 +20,9 @@ const (
 	GaddagMagicNumber      = "cgdg"
 	DawgMagicNumber        = "cdwg"
 	ReverseDawgMagicNumber = "rdwg"
+	// AlignedGaddagMagicNumber is the magic number of gaddags saved with
+	// SaveAligned.
+	AlignedGaddagMagicNumber = "cgda"
 )
 
 // NumArcsBitLoc is the bit location where the number of arcs start.
@@ -307,6 +310,45 @@ func (g *Gaddag) Write(stream io.Writer) {
 	log.Info().Msgf("Wrote nodes (num = %v)", len(g.SerializedNodes))
 }
 
+// SaveAligned saves the GADDAG to a file in a format that can be used
+// right from the file once it's memory-mapped, on little-endian machines.
+// It is the format of Write, but little-endian, and with zeroes after the
+// lexicon name and before the letter sets, so that every array starts at
+// a multiple of the size of its elements:
+// [4-byte magic number]
+// [1-byte length (LX_LEN)] [lexicon name] [zeroes up to a multiple of 4 bytes]
+// [alphabetlength] [letters...]
+// [lettersetlength] [4 zero bytes, if needed for a multiple of 8] [lettersets]
+// [nodelength] [nodes...]
+func (g *Gaddag) SaveAligned(filename string) {
+	g.SerializeElements()
+	file, err := os.Create(filename)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Could not create file")
+	}
+	stream := bufio.NewWriter(file)
+	stream.WriteString(AlignedGaddagMagicNumber)
+	bts := []byte(g.lexiconName)
+	stream.WriteByte(uint8(len(bts)))
+	stream.Write(bts)
+	offset := len(AlignedGaddagMagicNumber) + 1 + len(bts)
+	for ; offset%4 != 0; offset++ {
+		stream.WriteByte(0)
+	}
+	binary.Write(stream, binary.LittleEndian, g.SerializedAlphabet)
+	binary.Write(stream, binary.LittleEndian, g.NumLetterSets)
+	offset += 4*len(g.SerializedAlphabet) + 4
+	if offset%8 != 0 {
+		binary.Write(stream, binary.LittleEndian, uint32(0))
+	}
+	binary.Write(stream, binary.LittleEndian, g.SerializedLetterSets)
+	binary.Write(stream, binary.LittleEndian, uint32(len(g.SerializedNodes)))
+	binary.Write(stream, binary.LittleEndian, g.SerializedNodes)
+	stream.Flush()
+	file.Close()
+	log.Info().Msgf("Saved aligned gaddag to %v", filename)
+}
+
 // GenerateDawg makes a GADDAG with only one permutation of letters
 // allowed per word, the spelled-out permutation. We still treat it for
 // all intents and purposes as a GADDAG, but note that it only has one path!
@@ -317,55 +359,94 @@ func GenerateDawg(filename string, minimize bool, writeToFile bool, reverse bool
 		return gaddag
 	}
 	gaddag.lexiconName = strings.Split(filepath.Base(filename), ".")[0]
-	gaddag.Root = gaddag.createNode()
 	gaddag.Alphabet = alphabet
 	log.Info().Msgf("Read %v words", len(words))
 	if reverse {
 		log.Info().Msgf("Generating reverse dawg")
+		for idx, word := range words {
+			wordRunes := []rune(word)
+			for left, right := 0, len(wordRunes)-1; left < right; left, right = left+1, right-1 {
+				wordRunes[left], wordRunes[right] = wordRunes[right], wordRunes[left]
+			}
+			words[idx] = string(wordRunes)
+		}
 	}
+	gaddag.makeDawg(words, minimize)
+	if writeToFile {
+		mn := DawgMagicNumber
+		if reverse {
+			mn = ReverseDawgMagicNumber
+		}
+		gaddag.Save("out.dawg", mn)
+	}
+	return gaddag
+}
 
+// GenerateAlphagramDawg makes a DAWG of the alphagrams of the words, that
+// is, of their letters in alphabetical order, once for all the words that
+// are anagrams of each other. It is the lexicon of WordSmog, where a word
+// is good if any of its anagrams is.
+func GenerateAlphagramDawg(filename string, minimize bool, writeToFile bool) *Gaddag {
+	gaddag := &Gaddag{}
+	words, alphabet := getWordsFromFile(filename)
+	if words == nil {
+		return gaddag
+	}
+	gaddag.lexiconName = strings.Split(filepath.Base(filename), ".")[0]
+	gaddag.Alphabet = alphabet
+	log.Info().Msgf("Read %v words", len(words))
+
+	seen := make(map[string]bool)
+	alphagrams := []string{}
+	for _, word := range words {
+		wordRunes := []rune(word)
+		sort.Slice(wordRunes, func(i, j int) bool { return wordRunes[i] < wordRunes[j] })
+		alphagram := string(wordRunes)
+		if !seen[alphagram] {
+			seen[alphagram] = true
+			alphagrams = append(alphagrams, alphagram)
+		}
+	}
+	log.Info().Msgf("Found %v alphagrams", len(alphagrams))
+	gaddag.makeDawg(alphagrams, minimize)
+	if writeToFile {
+		gaddag.Save("out.dawg", DawgMagicNumber)
+	}
+	return gaddag
+}
+
+// makeDawg adds a path for every word, spelled out, and optionally
+// minimizes the result.
+func (g *Gaddag) makeDawg(words []string, minimize bool) {
+	g.Root = g.createNode()
 	for idx, word := range words {
 
 		if idx%10000 == 0 {
 			log.Debug().Msgf("%d...", idx)
 		}
-		st := gaddag.Root
+		st := g.Root
 		// Create path for a1..an-1:
 		wordRunes := []rune(word)
 
-		if reverse {
-			for left, right := 0, len(wordRunes)-1; left < right; left, right = left+1, right-1 {
-				wordRunes[left], wordRunes[right] = wordRunes[right], wordRunes[left]
-			}
-		}
-
 		n := len(wordRunes)
 		for j := 0; j < n-2; j++ {
-			st = st.addArc(wordRunes[j], gaddag)
+			st = st.addArc(wordRunes[j], g)
 		}
 
-		st = st.addFinalArc(wordRunes[n-2], wordRunes[n-1], gaddag)
+		st = st.addFinalArc(wordRunes[n-2], wordRunes[n-1], g)
 	}
-	log.Info().Msgf("Allocated arcs: %d states: %d", gaddag.AllocArcs,
-		gaddag.AllocStates)
+	log.Info().Msgf("Allocated arcs: %d states: %d", g.AllocArcs,
+		g.AllocStates)
 	// We need to also sort the arcs alphabetically prior to minimization/
 	// serialization.
-	traverseTreeAndExecute(gaddag.Root, func(node *Node) {
+	traverseTreeAndExecute(g.Root, func(node *Node) {
 		sort.Sort(ArcPtrSlice(node.arcs))
 	})
 	if minimize {
-		gaddag.Minimize()
+		g.Minimize()
 	} else {
 		log.Info().Msg("Not minimizing.")
 	}
-	if writeToFile {
-		mn := DawgMagicNumber
-		if reverse {
-			mn = ReverseDawgMagicNumber
-		}
-		gaddag.Save("out.dawg", mn)
-	}
-	return gaddag
 }
 
 func genGaddag(stream io.Reader, lexName string, minimize bool, writeToFile bool) *Gaddag {
@@ -40,6 +40,11 @@ func (g *Game) SetBackupMode(m BackupMode) {
 	} // otherwise, let the caller handle this.
 }
 
+// BackupMode returns the game's current backup mode.
+func (g *Game) BackupMode() BackupMode {
+	return g.backupMode
+}
+
 func (g *Game) backupState() {
 	if g.backupMode == InteractiveGameplayMode {
 		g.stackPtr = 0
@@ -165,6 +170,7 @@ func (g *Game) Copy() *Game {
 		board:          g.board.Copy(),
 		bag:            g.bag.Copy(randSource),
 		lexicon:        g.lexicon,
+		variant:        g.variant,
 		crossSetGen:    g.crossSetGen,
 		alph:           g.alph,
 		playing:        g.playing,
@@ -20,7 +20,7 @@ func TestChallengeVoid(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 	is.NoErr(err)
 	game, err := game.NewGame(rules, players)
@@ -41,7 +41,7 @@ func TestChallengeDoubleIsLegal(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 	g, _ := game.NewGame(rules, players)
 	alph := g.Alphabet()
@@ -67,7 +67,7 @@ func TestChallengeDoubleIsIllegal(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 	g, _ := game.NewGame(rules, players)
 	alph := g.Alphabet()
@@ -88,12 +88,48 @@ func TestChallengeDoubleIsIllegal(t *testing.T) {
 	is.Equal(g.History().Events[1].Type, pb.GameEvent_PHONY_TILES_RETURNED)
 }
 
+func TestChallengeWordSmog(t *testing.T) {
+	is := is.New(t)
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantWordSmog, "NWL18",
+		"English")
+	is.NoErr(err)
+	g, _ := game.NewGame(rules, players)
+	alph := g.Alphabet()
+	g.StartGame()
+	is.Equal(g.History().Variant, game.VariantWordSmog)
+	g.SetBackupMode(game.InteractiveGameplayMode)
+	g.SetPlayerOnTurn(0)
+	g.SetRackFor(0, alphabet.RackFromString("ABILITY", alph))
+	g.SetChallengeRule(pb.ChallengeRule_DOUBLE)
+	// BAILITY is an anagram of ABILITY, so it is good in WordSmog.
+	m := move.NewScoringMoveSimple(76, "8C", "BAILITY", "", alph)
+	_, err = g.ValidateMove(m)
+	is.NoErr(err)
+	err = g.PlayMove(m, true, 0)
+	is.NoErr(err)
+	legal, err := g.ChallengeEvent(0, 0)
+	is.NoErr(err)
+	is.True(legal)
+	is.Equal(g.History().Events[1].Type, pb.GameEvent_UNSUCCESSFUL_CHALLENGE_TURN_LOSS)
+
+	// But no anagram of SAWIFFET is a word.
+	g.SetRackFor(g.PlayerOnTurn(), alphabet.RackFromString("EFFISTW", alph))
+	g.SetChallengeRule(pb.ChallengeRule_VOID)
+	m = move.NewScoringMoveSimple(30, "D7", "S.WIFFET", "", alph)
+	_, err = g.ValidateMove(m)
+	is.Equal(err.Error(), "the play contained illegal words: SAWIFFET")
+}
+
 func TestChallengeEndOfGamePlusFive(t *testing.T) {
 	is := is.New(t)
 
 	gameHistory, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/some_isc_game.gcg")
 	is.NoErr(err)
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 
 	g, err := game.NewFromHistory(gameHistory, rules, 0)
@@ -121,7 +157,7 @@ func TestChallengeEndOfGamePhony(t *testing.T) {
 
 	gameHistory, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/some_isc_game.gcg")
 	is.NoErr(err)
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 
 	g, err := game.NewFromHistory(gameHistory, rules, 0)
@@ -153,7 +189,7 @@ func TestChallengeTripleUnsuccessful(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 	g, _ := game.NewGame(rules, players)
 	alph := g.Alphabet()
@@ -180,7 +216,7 @@ func TestChallengeTripleSuccessful(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, "NWL18",
+	rules, _ := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame, "NWL18",
 		"English")
 	g, _ := game.NewGame(rules, players)
 	alph := g.Alphabet()
@@ -95,9 +95,14 @@ func (g *Game) ToDisplayText() string {
 		addText(bts, p, hpadding, bagDisp[p-vpadding])
 	}
 
-	addText(bts, 12, hpadding, fmt.Sprintf("Turn %d:", g.turnnum))
+	// A bigger bag pushes the turn down.
+	turnRow := 12
+	if vpadding+len(bagDisp)+1 > turnRow {
+		turnRow = vpadding + len(bagDisp) + 1
+	}
+	addText(bts, turnRow, hpadding, fmt.Sprintf("Turn %d:", g.turnnum))
 
-	vpadding = 13
+	vpadding = turnRow + 1
 
 	for i, evt := range g.history.Events {
 		log.Debug().Msgf("Event %d: %v", i, evt)
@@ -108,7 +113,7 @@ func (g *Game) ToDisplayText() string {
 			summary(g.history.Events[g.turnnum-1]))
 	}
 
-	vpadding = 17
+	vpadding = turnRow + 5
 
 	if g.playing == pb.PlayState_GAME_OVER && g.turnnum == len(g.history.Events) {
 		addText(bts, vpadding, hpadding, "Game is over.")
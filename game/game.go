@@ -55,6 +55,7 @@ type Game struct {
 	crossSetGen cross_set.Generator
 	lexicon     lexicon.Lexicon
 	alph        *alphabet.Alphabet
+	variant     string
 	// board and bag will contain the latest (current) versions of these.
 	board              *board.GameBoard
 	letterDistribution *alphabet.LetterDistribution
@@ -98,6 +99,11 @@ func (g *Game) LexiconName() string {
 	return g.lexicon.Name()
 }
 
+// Variant returns the variant of the game, such as VariantWordSmog.
+func (g *Game) Variant() string {
+	return g.variant
+}
+
 func (g *Game) LastWordsFormed() []alphabet.MachineWord {
 	return g.lastWordsFormed
 }
@@ -159,6 +165,7 @@ func NewGame(rules *GameRules, playerinfo []*pb.PlayerInfo) (*Game, error) {
 	game.board = rules.Board().Copy()
 	game.crossSetGen = rules.CrossSetGen()
 	game.lexicon = rules.Lexicon()
+	game.variant = rules.Variant()
 	game.config = rules.Config()
 
 	game.players = make([]*playerState, len(playerinfo))
@@ -245,6 +252,7 @@ func (g *Game) StartGame() {
 		g.RackLettersFor(0), g.RackLettersFor(1),
 	}
 	g.history.Lexicon = g.Lexicon().Name()
+	g.history.Variant = g.variant
 	g.playing = pb.PlayState_PLAYING
 	g.history.PlayState = g.playing
 	g.turnnum = 0
@@ -438,7 +446,9 @@ func (g *Game) PlayMove(m *move.Move, addToHistory bool, millis int) error {
 		}
 		if g.playing == pb.PlayState_WAITING_FOR_FINAL_PASS {
 			g.playing = pb.PlayState_GAME_OVER
-			g.history.PlayState = g.playing
+			if g.history != nil {
+				g.history.PlayState = g.playing
+			}
 			log.Debug().Msg("waiting -> gameover transition")
 			// Note that the player "on turn" changes here, as we created
 			// a fake virtual turn on the pass. We need to calculate
@@ -507,7 +517,10 @@ func (g *Game) handleConsecutiveScorelessTurns(addToHistory bool) (bool, error)
 		ended = true
 		log.Debug().Msg("game ended with 6 scoreless turns")
 		g.playing = pb.PlayState_GAME_OVER
-		g.history.PlayState = g.playing
+		// Game copies (for sims and endgames) have no history.
+		if g.history != nil {
+			g.history.PlayState = g.playing
+		}
 
 		pts := g.calculateRackPts(g.onturn)
 		g.players[g.onturn].points -= pts
@@ -868,6 +881,11 @@ func (g *Game) ThrowRacksIn() {
 	g.players[1].throwRackIn(g.bag)
 }
 
+// ThrowRackIn throws the given player's rack back in the bag.
+func (g *Game) ThrowRackIn(playerIdx int) {
+	g.players[playerIdx].throwRackIn(g.bag)
+}
+
 // SetRandomRack sets the player's rack to a random rack drawn from the bag.
 // It tosses the current rack back in first. This is used for simulations.
 func (g *Game) SetRandomRack(playerIdx int) {
@@ -879,6 +897,33 @@ func (g *Game) SetRandomRack(playerIdx int) {
 	// 	Msg("set random rack")
 }
 
+// SetPartialRack sets the player's rack to the known tiles, and fills up the
+// rest of it with random tiles from the bag. It tosses the current rack back
+// in first. This is used for simulations where we have some idea of what
+// the player is holding. If the known tiles are not available, the player
+// gets a fully random rack and an error is returned.
+func (g *Game) SetPartialRack(playerIdx int, known []alphabet.MachineLetter) error {
+	if len(known) > RackTileLimit {
+		return fmt.Errorf("cannot set a partial rack of %v tiles", len(known))
+	}
+	tiles := make([]alphabet.MachineLetter, len(known), RackTileLimit)
+	for idx, ml := range known {
+		if ml.IsBlanked() {
+			ml = alphabet.BlankMachineLetter
+		}
+		tiles[idx] = ml
+	}
+	g.bag.PutBack(g.RackFor(playerIdx).TilesOn())
+	err := g.bag.RemoveTiles(tiles)
+	if err != nil {
+		g.players[playerIdx].setRackTiles(g.bag.DrawAtMost(RackTileLimit), g.alph)
+		return err
+	}
+	tiles = append(tiles, g.bag.DrawAtMost(RackTileLimit-len(tiles))...)
+	g.players[playerIdx].setRackTiles(tiles, g.alph)
+	return nil
+}
+
 // RackFor returns the rack for the player with the passed-in index
 func (g *Game) RackFor(playerIdx int) *alphabet.Rack {
 	return g.players[playerIdx].rack
@@ -971,6 +1016,11 @@ func (g *Game) SetPointsFor(player, pts int) {
 	g.players[player].points = pts
 }
 
+// ScorelessTurns returns the number of consecutive scoreless turns.
+func (g *Game) ScorelessTurns() int {
+	return g.scorelessTurns
+}
+
 func (g *Game) Alphabet() *alphabet.Alphabet {
 	return g.alph
 }
@@ -27,6 +27,18 @@ func TestMain(m *testing.M) {
 				panic(err)
 			}
 		}
+		dawgPath := filepath.Join(DefaultConfig.LexiconPath, "alphagrams", lex+".dawg")
+		if _, err := os.Stat(dawgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateAlphagramDawg(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.MkdirAll(filepath.Dir(dawgPath), 0755)
+			if err != nil {
+				panic(err)
+			}
+			err = os.Rename("out.dawg", dawgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
 	}
 	os.Exit(m.Run())
 }
@@ -117,3 +129,33 @@ func TestValidate(t *testing.T) {
 	is.Equal(g.history.Events[len(g.history.Events)-1].WordsFormed,
 		[]string{"DIKTAT", "HIST"})
 }
+
+func TestSetPartialRack(t *testing.T) {
+	is := is.New(t)
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, _ := NewBasicGameRules(&DefaultConfig, board.CrosswordGameBoard, "English")
+	game, _ := NewGame(rules, players)
+	game.StartGame()
+
+	known, err := alphabet.ToMachineWord("QU?", game.Alphabet())
+	is.NoErr(err)
+	err = game.SetPartialRack(1, known)
+	is.NoErr(err)
+	is.Equal(game.RackFor(1).NumTiles(), uint8(7))
+	is.True(game.RackFor(1).Has(known[0]))
+	is.True(game.RackFor(1).Has(known[1]))
+	is.True(game.RackFor(1).Has(alphabet.BlankMachineLetter))
+	is.Equal(game.bag.TilesRemaining(), 86)
+
+	// There is only one Q.
+	known, err = alphabet.ToMachineWord("QQ", game.Alphabet())
+	is.NoErr(err)
+	err = game.SetPartialRack(1, known)
+	is.True(err != nil)
+	// The player should still have a full rack.
+	is.Equal(game.RackFor(1).NumTiles(), uint8(7))
+	is.Equal(game.bag.TilesRemaining(), 86)
+}
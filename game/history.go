@@ -1,28 +1,48 @@
 package game
 
 import (
-	"strings"
-
+	"github.com/domino14/macondo/alphabet"
 	"github.com/domino14/macondo/board"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
 )
 
+const (
+	// VariantCrosswordGame is the classic game, on a 15x15 board.
+	VariantCrosswordGame = "CrosswordGame"
+	// VariantSuperCrosswordGame is played on a 21x21 board, with twice as
+	// many tiles.
+	VariantSuperCrosswordGame = "SuperCrosswordGame"
+	// VariantWordSmog is played on the classic board, but a word is valid
+	// if any of its anagrams is in the lexicon.
+	VariantWordSmog = "WordSmog"
+)
+
 // HistoryToVariant takes in a game history and returns the board configuration
 // and letter distribution name.
 func HistoryToVariant(h *pb.GameHistory) (boardLayout []string, letterDistributionName string) {
 
 	switch h.Variant {
-	case "CrosswordGame":
+	case VariantCrosswordGame:
+		boardLayout = board.CrosswordGameBoard
+	case VariantSuperCrosswordGame:
+		boardLayout = board.SuperCrosswordGameBoard
+	case VariantWordSmog:
 		boardLayout = board.CrosswordGameBoard
 	default:
 		boardLayout = board.CrosswordGameBoard
 	}
-	letterDistributionName = "english"
-	switch {
-	case strings.HasPrefix(h.Lexicon, "OSPS"):
-		letterDistributionName = "polish"
-	case strings.HasPrefix(h.Lexicon, "FISE"):
-		letterDistributionName = "spanish"
+	letterDistributionName = alphabet.DistributionNameForLexicon(h.Lexicon)
+	if h.Variant == VariantSuperCrosswordGame && letterDistributionName == "english" {
+		// Only English has a distribution made for the bigger board.
+		letterDistributionName = "englishsuper"
 	}
 	return boardLayout, letterDistributionName
 }
+
+// boardVariant returns the variant that is played on the board.
+func boardVariant(b *board.GameBoard) string {
+	if b.Dim() == len(board.SuperCrosswordGameBoard) {
+		return VariantSuperCrosswordGame
+	}
+	return VariantCrosswordGame
+}
@@ -19,6 +19,7 @@ type GameRules struct {
 	dist        *alphabet.LetterDistribution
 	lexicon     lexicon.Lexicon
 	crossSetGen cross_set.Generator
+	variant     string
 }
 
 func (g GameRules) Config() *config.Config {
@@ -45,6 +46,10 @@ func (g GameRules) CrossSetGen() cross_set.Generator {
 	return g.crossSetGen
 }
 
+func (g GameRules) Variant() string {
+	return g.variant
+}
+
 func NewBasicGameRules(cfg *config.Config, boardLayout []string,
 	letterDistributionName string) (*GameRules, error) {
 
@@ -58,23 +63,30 @@ func NewBasicGameRules(cfg *config.Config, boardLayout []string,
 		return nil, errors.New("type-assertion failed (letterDistribution)")
 	}
 
+	bd := board.MakeBoard(boardLayout)
 	rules := &GameRules{
 		cfg:         cfg,
 		dist:        distLD,
-		board:       board.MakeBoard(boardLayout),
+		board:       bd,
 		lexicon:     lexicon.AcceptAll{Alph: distLD.Alphabet()},
 		crossSetGen: cross_set.CrossScoreOnlyGenerator{Dist: distLD},
+		variant:     boardVariant(bd),
 	}
 	return rules, nil
 }
 
 func NewGameRules(cfg *config.Config, dist *alphabet.LetterDistribution,
-	board *board.GameBoard, lex lexicon.Lexicon, cset cross_set.Generator) *GameRules {
+	board *board.GameBoard, lex lexicon.Lexicon, cset cross_set.Generator,
+	variant string) *GameRules {
+	if variant == "" {
+		variant = boardVariant(board)
+	}
 	return &GameRules{
 		cfg:         cfg,
 		dist:        dist,
 		board:       board,
 		lexicon:     lex,
 		crossSetGen: cset,
+		variant:     variant,
 	}
 }
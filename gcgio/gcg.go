@@ -53,6 +53,7 @@ const (
 	EndRackPointsToken
 	TimePenaltyToken
 	LastRackPenaltyToken
+	VariantToken
 )
 
 type gcgdatum struct {
@@ -72,6 +73,7 @@ const (
 	MoveRegex               = `>(?P<nick>\S+):\s+(?P<rack>\S+)\s+(?P<pos>\w+)\s+(?P<play>[\w\\.]+)\s+\+(?P<score>\d+)\s+(?P<cumul>\d+)`
 	NoteRegex               = `#note (?P<note>.+)`
 	LexiconRegex            = `#lexicon (?P<lexicon>.+)`
+	VariantRegex            = `#variant (?P<variant>\S+)`
 	CharacterEncodingRegex  = `#character-encoding (?P<encoding>[[:graph:]]+)`
 	PhonyTilesReturnedRegex = `>(?P<nick>\S+):\s+(?P<rack>\S+)\s+--\s+-(?P<lost_score>\d+)\s+(?P<cumul>\d+)`
 	PassRegex               = `>(?P<nick>\S+):\s+(?P<rack>\S+)\s+-\s+\+0\s+(?P<cumul>\d+)`
@@ -111,6 +113,7 @@ func init() {
 		{MoveToken, regexp.MustCompile(MoveRegex)},
 		{NoteToken, regexp.MustCompile(NoteRegex)},
 		{LexiconToken, regexp.MustCompile(LexiconRegex)},
+		{VariantToken, regexp.MustCompile(VariantRegex)},
 		{PhonyTilesReturnedToken, regexp.MustCompile(PhonyTilesReturnedRegex)},
 		{PassToken, regexp.MustCompile(PassRegex)},
 		{ChallengeBonusToken, regexp.MustCompile(ChallengeBonusRegex)},
@@ -140,7 +143,7 @@ func (p *parser) addEventOrPragma(cfg *config.Config, token Token, match []strin
 		if p.game == nil {
 
 			if p.history.Variant == "" {
-				p.history.Variant = "CrosswordGame"
+				p.history.Variant = game.VariantCrosswordGame
 			}
 			if p.history.Lexicon == "" {
 				p.history.Lexicon = cfg.DefaultLexicon
@@ -260,6 +263,12 @@ func (p *parser) addEventOrPragma(cfg *config.Config, token Token, match []strin
 		}
 		p.history.Lexicon = match[1]
 		return nil
+	case VariantToken:
+		if len(p.history.Events) > 0 {
+			return errPragmaPrecedeEvent
+		}
+		p.history.Variant = match[1]
+		return nil
 	case PhonyTilesReturnedToken:
 		evt := &pb.GameEvent{}
 		evt.Nickname = match[1]
@@ -543,6 +552,11 @@ func writeGCGHeader(s *strings.Builder, h *pb.GameHistory, addlInfo bool) {
 			s.WriteString("#lexicon " + h.Lexicon + "\n")
 		}
 	}
+	// The classic game is what a GCG is assumed to be without a variant;
+	// any other board can't be replayed without it.
+	if h.Variant != "" && h.Variant != game.VariantCrosswordGame {
+		s.WriteString("#variant " + h.Variant + "\n")
+	}
 	log.Debug().Msg("wrote header")
 }
 
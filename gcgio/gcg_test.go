@@ -288,3 +288,39 @@ func TestIsBingo(t *testing.T) {
 	assert.True(t, history.Events[0].IsBingo)
 	assert.False(t, history.Events[1].IsBingo)
 }
+
+func TestSuperCrosswordGame(t *testing.T) {
+	is := is.New(t)
+	history, err := ParseGCG(&DefaultConfig, "./testdata/super.gcg")
+	is.NoErr(err)
+	is.Equal(history.Variant, game.VariantSuperCrosswordGame)
+
+	boardLayout, ldName := game.HistoryToVariant(history)
+	is.Equal(ldName, "englishsuper")
+	rules, err := game.NewBasicGameRules(&DefaultConfig, boardLayout, ldName)
+	is.NoErr(err)
+	g, err := game.NewFromHistory(history, rules, 0)
+	is.NoErr(err)
+	is.Equal(g.Board().Dim(), 21)
+	is.Equal(g.Bag().TilesRemaining(), 200-14)
+
+	err = g.PlayToTurn(len(history.Events))
+	is.NoErr(err)
+	// 8T EJ, on the column past the edge of a standard board.
+	is.Equal(g.Board().GetLetter(7, 20).UserVisible(g.Alphabet()), 'J')
+	is.Equal(g.PointsFor(0), 179)
+
+	gcgstr, err := GameHistoryToGCG(history, true)
+	is.NoErr(err)
+	is.True(strings.Contains(gcgstr, "#variant SuperCrosswordGame\n"))
+
+	// The variant is written even without the additional info, or the
+	// game couldn't be replayed on the right board.
+	gcgstr, err = GameHistoryToGCG(history, false)
+	is.NoErr(err)
+	is.True(strings.Contains(gcgstr, "#variant SuperCrosswordGame\n"))
+	is.True(!strings.Contains(gcgstr, "#lexicon"))
+	reparsed, err := ParseGCGFromReader(&DefaultConfig, strings.NewReader(gcgstr))
+	is.NoErr(err)
+	is.Equal(reparsed.Variant, game.VariantSuperCrosswordGame)
+}
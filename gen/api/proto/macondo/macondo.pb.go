@@ -686,6 +686,346 @@ func (x *PlayerInfo) GetUserId() string {
 	return ""
 }
 
+// SimResults are the results of a Monte Carlo simulation of a position.
+type SimResults struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// plays are sorted from best to worst.
+	Plays      []*SimmedPlayStats `protobuf:"bytes,1,rep,name=plays,proto3" json:"plays,omitempty"`
+	Plies      int32              `protobuf:"varint,2,opt,name=plies,proto3" json:"plies,omitempty"`
+	Iterations int32              `protobuf:"varint,3,opt,name=iterations,proto3" json:"iterations,omitempty"`
+	// z_value is the z-score that the confidence intervals were computed
+	// with; for example 1.96 for a 95% confidence interval.
+	ZValue float64 `protobuf:"fixed64,4,opt,name=z_value,json=zValue,proto3" json:"z_value,omitempty"`
+}
+
+func (x *SimResults) Reset() {
+	*x = SimResults{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SimResults) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimResults) ProtoMessage() {}
+
+func (x *SimResults) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimResults.ProtoReflect.Descriptor instead.
+func (*SimResults) Descriptor() ([]byte, []int) {
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SimResults) GetPlays() []*SimmedPlayStats {
+	if x != nil {
+		return x.Plays
+	}
+	return nil
+}
+
+func (x *SimResults) GetPlies() int32 {
+	if x != nil {
+		return x.Plies
+	}
+	return 0
+}
+
+func (x *SimResults) GetIterations() int32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+func (x *SimResults) GetZValue() float64 {
+	if x != nil {
+		return x.ZValue
+	}
+	return 0
+}
+
+// SimStat summarizes a statistic that was collected during a simulation.
+type SimStat struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mean  float64 `protobuf:"fixed64,1,opt,name=mean,proto3" json:"mean,omitempty"`
+	Stdev float64 `protobuf:"fixed64,2,opt,name=stdev,proto3" json:"stdev,omitempty"`
+	// ci_low and ci_high are the bounds of the confidence interval of the
+	// mean.
+	CiLow      float64 `protobuf:"fixed64,3,opt,name=ci_low,json=ciLow,proto3" json:"ci_low,omitempty"`
+	CiHigh     float64 `protobuf:"fixed64,4,opt,name=ci_high,json=ciHigh,proto3" json:"ci_high,omitempty"`
+	Iterations int32   `protobuf:"varint,5,opt,name=iterations,proto3" json:"iterations,omitempty"`
+}
+
+func (x *SimStat) Reset() {
+	*x = SimStat{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SimStat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimStat) ProtoMessage() {}
+
+func (x *SimStat) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimStat.ProtoReflect.Descriptor instead.
+func (*SimStat) Descriptor() ([]byte, []int) {
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SimStat) GetMean() float64 {
+	if x != nil {
+		return x.Mean
+	}
+	return 0
+}
+
+func (x *SimStat) GetStdev() float64 {
+	if x != nil {
+		return x.Stdev
+	}
+	return 0
+}
+
+func (x *SimStat) GetCiLow() float64 {
+	if x != nil {
+		return x.CiLow
+	}
+	return 0
+}
+
+func (x *SimStat) GetCiHigh() float64 {
+	if x != nil {
+		return x.CiHigh
+	}
+	return 0
+}
+
+func (x *SimStat) GetIterations() int32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return 0
+}
+
+// PlyStats are the statistics of the plays made on a single ply of a
+// simulation. Ply 1 is the opponent's reply.
+type PlyStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Ply   int32    `protobuf:"varint,1,opt,name=ply,proto3" json:"ply,omitempty"`
+	Score *SimStat `protobuf:"bytes,2,opt,name=score,proto3" json:"score,omitempty"`
+	// bingo is the fraction of iterations in which the play was a bingo.
+	Bingo *SimStat `protobuf:"bytes,3,opt,name=bingo,proto3" json:"bingo,omitempty"`
+}
+
+func (x *PlyStats) Reset() {
+	*x = PlyStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *PlyStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlyStats) ProtoMessage() {}
+
+func (x *PlyStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlyStats.ProtoReflect.Descriptor instead.
+func (*PlyStats) Descriptor() ([]byte, []int) {
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PlyStats) GetPly() int32 {
+	if x != nil {
+		return x.Ply
+	}
+	return 0
+}
+
+func (x *PlyStats) GetScore() *SimStat {
+	if x != nil {
+		return x.Score
+	}
+	return nil
+}
+
+func (x *PlyStats) GetBingo() *SimStat {
+	if x != nil {
+		return x.Bingo
+	}
+	return nil
+}
+
+// SimmedPlayStats are the results for a single play in a simulation.
+type SimmedPlayStats struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Move         *GameEvent `protobuf:"bytes,1,opt,name=move,proto3" json:"move,omitempty"`
+	Description  string     `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	Score        int32      `protobuf:"varint,3,opt,name=score,proto3" json:"score,omitempty"`
+	StaticEquity float64    `protobuf:"fixed64,4,opt,name=static_equity,json=staticEquity,proto3" json:"static_equity,omitempty"`
+	// equity is the spread difference at the end of the simulated plies,
+	// plus the value of the leftover tiles.
+	Equity   *SimStat `protobuf:"bytes,5,opt,name=equity,proto3" json:"equity,omitempty"`
+	Leftover *SimStat `protobuf:"bytes,6,opt,name=leftover,proto3" json:"leftover,omitempty"`
+	// win_pct is only set if the simulation also estimated win percentages.
+	WinPct   *SimStat    `protobuf:"bytes,7,opt,name=win_pct,json=winPct,proto3" json:"win_pct,omitempty"`
+	PlyStats []*PlyStats `protobuf:"bytes,8,rep,name=ply_stats,json=plyStats,proto3" json:"ply_stats,omitempty"`
+	// pruned is set if the play was found to be statistically worse than
+	// the best play, and was no longer simmed.
+	Pruned bool `protobuf:"varint,9,opt,name=pruned,proto3" json:"pruned,omitempty"`
+}
+
+func (x *SimmedPlayStats) Reset() {
+	*x = SimmedPlayStats{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SimmedPlayStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SimmedPlayStats) ProtoMessage() {}
+
+func (x *SimmedPlayStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SimmedPlayStats.ProtoReflect.Descriptor instead.
+func (*SimmedPlayStats) Descriptor() ([]byte, []int) {
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SimmedPlayStats) GetMove() *GameEvent {
+	if x != nil {
+		return x.Move
+	}
+	return nil
+}
+
+func (x *SimmedPlayStats) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *SimmedPlayStats) GetScore() int32 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SimmedPlayStats) GetStaticEquity() float64 {
+	if x != nil {
+		return x.StaticEquity
+	}
+	return 0
+}
+
+func (x *SimmedPlayStats) GetEquity() *SimStat {
+	if x != nil {
+		return x.Equity
+	}
+	return nil
+}
+
+func (x *SimmedPlayStats) GetLeftover() *SimStat {
+	if x != nil {
+		return x.Leftover
+	}
+	return nil
+}
+
+func (x *SimmedPlayStats) GetWinPct() *SimStat {
+	if x != nil {
+		return x.WinPct
+	}
+	return nil
+}
+
+func (x *SimmedPlayStats) GetPlyStats() []*PlyStats {
+	if x != nil {
+		return x.PlyStats
+	}
+	return nil
+}
+
+func (x *SimmedPlayStats) GetPruned() bool {
+	if x != nil {
+		return x.Pruned
+	}
+	return false
+}
+
 type BotRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -697,7 +1037,7 @@ type BotRequest struct {
 func (x *BotRequest) Reset() {
 	*x = BotRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_macondo_macondo_proto_msgTypes[3]
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -710,7 +1050,7 @@ func (x *BotRequest) String() string {
 func (*BotRequest) ProtoMessage() {}
 
 func (x *BotRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_macondo_macondo_proto_msgTypes[3]
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -723,7 +1063,7 @@ func (x *BotRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BotRequest.ProtoReflect.Descriptor instead.
 func (*BotRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{3}
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *BotRequest) GetGameHistory() *GameHistory {
@@ -747,7 +1087,7 @@ type BotResponse struct {
 func (x *BotResponse) Reset() {
 	*x = BotResponse{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_api_proto_macondo_macondo_proto_msgTypes[4]
+		mi := &file_api_proto_macondo_macondo_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -760,7 +1100,7 @@ func (x *BotResponse) String() string {
 func (*BotResponse) ProtoMessage() {}
 
 func (x *BotResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_macondo_macondo_proto_msgTypes[4]
+	mi := &file_api_proto_macondo_macondo_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -773,7 +1113,7 @@ func (x *BotResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BotResponse.ProtoReflect.Descriptor instead.
 func (*BotResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{4}
+	return file_api_proto_macondo_macondo_proto_rawDescGZIP(), []int{8}
 }
 
 func (m *BotResponse) GetResponse() isBotResponse_Response {
@@ -912,32 +1252,78 @@ var file_api_proto_macondo_macondo_proto_rawDesc = []byte{
 	0x69, 0x63, 0x6b, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x65, 0x61, 0x6c, 0x5f,
 	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x61, 0x6c,
 	0x4e, 0x61, 0x6d, 0x65, 0x12, 0x17, 0x0a, 0x07, 0x75, 0x73, 0x65, 0x72, 0x5f, 0x69, 0x64, 0x18,
-	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x45, 0x0a,
-	0x0a, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x67,
-	0x61, 0x6d, 0x65, 0x5f, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x47, 0x61, 0x6d, 0x65,
-	0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x0b, 0x67, 0x61, 0x6d, 0x65, 0x48, 0x69, 0x73,
-	0x74, 0x6f, 0x72, 0x79, 0x22, 0x5b, 0x0a, 0x0b, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f,
-	0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x75, 0x73, 0x65, 0x72, 0x49, 0x64, 0x22, 0x8b, 0x01,
+	0x0a, 0x0a, 0x53, 0x69, 0x6d, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x12, 0x2e, 0x0a, 0x05,
+	0x70, 0x6c, 0x61, 0x79, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x6d, 0x61,
+	0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x53, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x50, 0x6c, 0x61, 0x79,
+	0x53, 0x74, 0x61, 0x74, 0x73, 0x52, 0x05, 0x70, 0x6c, 0x61, 0x79, 0x73, 0x12, 0x14, 0x0a, 0x05,
+	0x70, 0x6c, 0x69, 0x65, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x70, 0x6c, 0x69,
+	0x65, 0x73, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x12, 0x17, 0x0a, 0x07, 0x7a, 0x5f, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x06, 0x7a, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x22, 0x83, 0x01, 0x0a, 0x07,
+	0x53, 0x69, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x65, 0x61, 0x6e, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6d, 0x65, 0x61, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x73,
+	0x74, 0x64, 0x65, 0x76, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x73, 0x74, 0x64, 0x65,
+	0x76, 0x12, 0x15, 0x0a, 0x06, 0x63, 0x69, 0x5f, 0x6c, 0x6f, 0x77, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x05, 0x63, 0x69, 0x4c, 0x6f, 0x77, 0x12, 0x17, 0x0a, 0x07, 0x63, 0x69, 0x5f, 0x68,
+	0x69, 0x67, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x63, 0x69, 0x48, 0x69, 0x67,
+	0x68, 0x12, 0x1e, 0x0a, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18,
+	0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0a, 0x69, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x73, 0x22, 0x6c, 0x0a, 0x08, 0x50, 0x6c, 0x79, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x10, 0x0a,
+	0x03, 0x70, 0x6c, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x03, 0x70, 0x6c, 0x79, 0x12,
+	0x26, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10,
+	0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x53, 0x69, 0x6d, 0x53, 0x74, 0x61, 0x74,
+	0x52, 0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x12, 0x26, 0x0a, 0x05, 0x62, 0x69, 0x6e, 0x67, 0x6f,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f,
+	0x2e, 0x53, 0x69, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x52, 0x05, 0x62, 0x69, 0x6e, 0x67, 0x6f, 0x22,
+	0xe1, 0x02, 0x0a, 0x0f, 0x53, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x50, 0x6c, 0x61, 0x79, 0x53, 0x74,
+	0x61, 0x74, 0x73, 0x12, 0x26, 0x0a, 0x04, 0x6d, 0x6f, 0x76, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
 	0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x47, 0x61, 0x6d, 0x65,
-	0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x16, 0x0a,
-	0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x05,
-	0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x0a, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
-	0x65, 0x2a, 0x43, 0x0a, 0x09, 0x50, 0x6c, 0x61, 0x79, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x0b,
-	0x0a, 0x07, 0x50, 0x4c, 0x41, 0x59, 0x49, 0x4e, 0x47, 0x10, 0x00, 0x12, 0x1a, 0x0a, 0x16, 0x57,
-	0x41, 0x49, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x46, 0x4f, 0x52, 0x5f, 0x46, 0x49, 0x4e, 0x41, 0x4c,
-	0x5f, 0x50, 0x41, 0x53, 0x53, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x47, 0x41, 0x4d, 0x45, 0x5f,
-	0x4f, 0x56, 0x45, 0x52, 0x10, 0x02, 0x2a, 0x5c, 0x0a, 0x0d, 0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65,
-	0x6e, 0x67, 0x65, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x56, 0x4f, 0x49, 0x44, 0x10,
-	0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x49, 0x4e, 0x47, 0x4c, 0x45, 0x10, 0x01, 0x12, 0x0a, 0x0a,
-	0x06, 0x44, 0x4f, 0x55, 0x42, 0x4c, 0x45, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x46, 0x49, 0x56,
-	0x45, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x10, 0x03, 0x12, 0x0d, 0x0a, 0x09, 0x54, 0x45, 0x4e,
-	0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x10, 0x04, 0x12, 0x0a, 0x0a, 0x06, 0x54, 0x52, 0x49, 0x50,
-	0x4c, 0x45, 0x10, 0x05, 0x42, 0x33, 0x5a, 0x31, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63,
-	0x6f, 0x6d, 0x2f, 0x64, 0x6f, 0x6d, 0x69, 0x6e, 0x6f, 0x31, 0x34, 0x2f, 0x6d, 0x61, 0x63, 0x6f,
-	0x6e, 0x64, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74,
-	0x6f, 0x2f, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+	0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x04, 0x6d, 0x6f, 0x76, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x64,
+	0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0b, 0x64, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a,
+	0x05, 0x73, 0x63, 0x6f, 0x72, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x73, 0x63,
+	0x6f, 0x72, 0x65, 0x12, 0x23, 0x0a, 0x0d, 0x73, 0x74, 0x61, 0x74, 0x69, 0x63, 0x5f, 0x65, 0x71,
+	0x75, 0x69, 0x74, 0x79, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x73, 0x74, 0x61, 0x74,
+	0x69, 0x63, 0x45, 0x71, 0x75, 0x69, 0x74, 0x79, 0x12, 0x28, 0x0a, 0x06, 0x65, 0x71, 0x75, 0x69,
+	0x74, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e,
+	0x64, 0x6f, 0x2e, 0x53, 0x69, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x52, 0x06, 0x65, 0x71, 0x75, 0x69,
+	0x74, 0x79, 0x12, 0x2c, 0x0a, 0x08, 0x6c, 0x65, 0x66, 0x74, 0x6f, 0x76, 0x65, 0x72, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x53,
+	0x69, 0x6d, 0x53, 0x74, 0x61, 0x74, 0x52, 0x08, 0x6c, 0x65, 0x66, 0x74, 0x6f, 0x76, 0x65, 0x72,
+	0x12, 0x29, 0x0a, 0x07, 0x77, 0x69, 0x6e, 0x5f, 0x70, 0x63, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x10, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x53, 0x69, 0x6d, 0x53,
+	0x74, 0x61, 0x74, 0x52, 0x06, 0x77, 0x69, 0x6e, 0x50, 0x63, 0x74, 0x12, 0x2e, 0x0a, 0x09, 0x70,
+	0x6c, 0x79, 0x5f, 0x73, 0x74, 0x61, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11,
+	0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2e, 0x50, 0x6c, 0x79, 0x53, 0x74, 0x61, 0x74,
+	0x73, 0x52, 0x08, 0x70, 0x6c, 0x79, 0x53, 0x74, 0x61, 0x74, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x70,
+	0x72, 0x75, 0x6e, 0x65, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x70, 0x72, 0x75,
+	0x6e, 0x65, 0x64, 0x22, 0x45, 0x0a, 0x0a, 0x42, 0x6f, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x37, 0x0a, 0x0c, 0x67, 0x61, 0x6d, 0x65, 0x5f, 0x68, 0x69, 0x73, 0x74, 0x6f, 0x72,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64,
+	0x6f, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x52, 0x0b, 0x67,
+	0x61, 0x6d, 0x65, 0x48, 0x69, 0x73, 0x74, 0x6f, 0x72, 0x79, 0x22, 0x5b, 0x0a, 0x0b, 0x42, 0x6f,
+	0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x28, 0x0a, 0x04, 0x6d, 0x6f, 0x76,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64,
+	0x6f, 0x2e, 0x47, 0x61, 0x6d, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6d,
+	0x6f, 0x76, 0x65, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x0a, 0x0a, 0x08, 0x72,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x2a, 0x43, 0x0a, 0x09, 0x50, 0x6c, 0x61, 0x79, 0x53,
+	0x74, 0x61, 0x74, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x4c, 0x41, 0x59, 0x49, 0x4e, 0x47, 0x10,
+	0x00, 0x12, 0x1a, 0x0a, 0x16, 0x57, 0x41, 0x49, 0x54, 0x49, 0x4e, 0x47, 0x5f, 0x46, 0x4f, 0x52,
+	0x5f, 0x46, 0x49, 0x4e, 0x41, 0x4c, 0x5f, 0x50, 0x41, 0x53, 0x53, 0x10, 0x01, 0x12, 0x0d, 0x0a,
+	0x09, 0x47, 0x41, 0x4d, 0x45, 0x5f, 0x4f, 0x56, 0x45, 0x52, 0x10, 0x02, 0x2a, 0x5c, 0x0a, 0x0d,
+	0x43, 0x68, 0x61, 0x6c, 0x6c, 0x65, 0x6e, 0x67, 0x65, 0x52, 0x75, 0x6c, 0x65, 0x12, 0x08, 0x0a,
+	0x04, 0x56, 0x4f, 0x49, 0x44, 0x10, 0x00, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x49, 0x4e, 0x47, 0x4c,
+	0x45, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x44, 0x4f, 0x55, 0x42, 0x4c, 0x45, 0x10, 0x02, 0x12,
+	0x0e, 0x0a, 0x0a, 0x46, 0x49, 0x56, 0x45, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x10, 0x03, 0x12,
+	0x0d, 0x0a, 0x09, 0x54, 0x45, 0x4e, 0x5f, 0x50, 0x4f, 0x49, 0x4e, 0x54, 0x10, 0x04, 0x12, 0x0a,
+	0x0a, 0x06, 0x54, 0x52, 0x49, 0x50, 0x4c, 0x45, 0x10, 0x05, 0x42, 0x33, 0x5a, 0x31, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x64, 0x6f, 0x6d, 0x69, 0x6e, 0x6f, 0x31,
+	0x34, 0x2f, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x2f, 0x67, 0x65, 0x6e, 0x2f, 0x61, 0x70,
+	0x69, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6d, 0x61, 0x63, 0x6f, 0x6e, 0x64, 0x6f, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
 }
 
 var (
@@ -953,7 +1339,7 @@ func file_api_proto_macondo_macondo_proto_rawDescGZIP() []byte {
 }
 
 var file_api_proto_macondo_macondo_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_api_proto_macondo_macondo_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_api_proto_macondo_macondo_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
 var file_api_proto_macondo_macondo_proto_goTypes = []interface{}{
 	(PlayState)(0),           // 0: macondo.PlayState
 	(ChallengeRule)(0),       // 1: macondo.ChallengeRule
@@ -962,23 +1348,35 @@ var file_api_proto_macondo_macondo_proto_goTypes = []interface{}{
 	(*GameHistory)(nil),      // 4: macondo.GameHistory
 	(*GameEvent)(nil),        // 5: macondo.GameEvent
 	(*PlayerInfo)(nil),       // 6: macondo.PlayerInfo
-	(*BotRequest)(nil),       // 7: macondo.BotRequest
-	(*BotResponse)(nil),      // 8: macondo.BotResponse
+	(*SimResults)(nil),       // 7: macondo.SimResults
+	(*SimStat)(nil),          // 8: macondo.SimStat
+	(*PlyStats)(nil),         // 9: macondo.PlyStats
+	(*SimmedPlayStats)(nil),  // 10: macondo.SimmedPlayStats
+	(*BotRequest)(nil),       // 11: macondo.BotRequest
+	(*BotResponse)(nil),      // 12: macondo.BotResponse
 }
 var file_api_proto_macondo_macondo_proto_depIdxs = []int32{
-	5, // 0: macondo.GameHistory.events:type_name -> macondo.GameEvent
-	6, // 1: macondo.GameHistory.players:type_name -> macondo.PlayerInfo
-	1, // 2: macondo.GameHistory.challenge_rule:type_name -> macondo.ChallengeRule
-	0, // 3: macondo.GameHistory.play_state:type_name -> macondo.PlayState
-	2, // 4: macondo.GameEvent.type:type_name -> macondo.GameEvent.Type
-	3, // 5: macondo.GameEvent.direction:type_name -> macondo.GameEvent.Direction
-	4, // 6: macondo.BotRequest.game_history:type_name -> macondo.GameHistory
-	5, // 7: macondo.BotResponse.move:type_name -> macondo.GameEvent
-	8, // [8:8] is the sub-list for method output_type
-	8, // [8:8] is the sub-list for method input_type
-	8, // [8:8] is the sub-list for extension type_name
-	8, // [8:8] is the sub-list for extension extendee
-	0, // [0:8] is the sub-list for field type_name
+	5,  // 0: macondo.GameHistory.events:type_name -> macondo.GameEvent
+	6,  // 1: macondo.GameHistory.players:type_name -> macondo.PlayerInfo
+	1,  // 2: macondo.GameHistory.challenge_rule:type_name -> macondo.ChallengeRule
+	0,  // 3: macondo.GameHistory.play_state:type_name -> macondo.PlayState
+	2,  // 4: macondo.GameEvent.type:type_name -> macondo.GameEvent.Type
+	3,  // 5: macondo.GameEvent.direction:type_name -> macondo.GameEvent.Direction
+	10, // 6: macondo.SimResults.plays:type_name -> macondo.SimmedPlayStats
+	8,  // 7: macondo.PlyStats.score:type_name -> macondo.SimStat
+	8,  // 8: macondo.PlyStats.bingo:type_name -> macondo.SimStat
+	5,  // 9: macondo.SimmedPlayStats.move:type_name -> macondo.GameEvent
+	8,  // 10: macondo.SimmedPlayStats.equity:type_name -> macondo.SimStat
+	8,  // 11: macondo.SimmedPlayStats.leftover:type_name -> macondo.SimStat
+	8,  // 12: macondo.SimmedPlayStats.win_pct:type_name -> macondo.SimStat
+	9,  // 13: macondo.SimmedPlayStats.ply_stats:type_name -> macondo.PlyStats
+	4,  // 14: macondo.BotRequest.game_history:type_name -> macondo.GameHistory
+	5,  // 15: macondo.BotResponse.move:type_name -> macondo.GameEvent
+	16, // [16:16] is the sub-list for method output_type
+	16, // [16:16] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_macondo_macondo_proto_init() }
@@ -1024,7 +1422,7 @@ func file_api_proto_macondo_macondo_proto_init() {
 			}
 		}
 		file_api_proto_macondo_macondo_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*BotRequest); i {
+			switch v := v.(*SimResults); i {
 			case 0:
 				return &v.state
 			case 1:
@@ -1036,6 +1434,54 @@ func file_api_proto_macondo_macondo_proto_init() {
 			}
 		}
 		file_api_proto_macondo_macondo_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SimStat); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_macondo_macondo_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*PlyStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_macondo_macondo_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SimmedPlayStats); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_macondo_macondo_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*BotRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_api_proto_macondo_macondo_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*BotResponse); i {
 			case 0:
 				return &v.state
@@ -1048,7 +1494,7 @@ func file_api_proto_macondo_macondo_proto_init() {
 			}
 		}
 	}
-	file_api_proto_macondo_macondo_proto_msgTypes[4].OneofWrappers = []interface{}{
+	file_api_proto_macondo_macondo_proto_msgTypes[8].OneofWrappers = []interface{}{
 		(*BotResponse_Move)(nil),
 		(*BotResponse_Error)(nil),
 	}
@@ -1058,7 +1504,7 @@ func file_api_proto_macondo_macondo_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_api_proto_macondo_macondo_proto_rawDesc,
 			NumEnums:      4,
-			NumMessages:   5,
+			NumMessages:   9,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -2,7 +2,7 @@ module github.com/domino14/macondo
 
 require (
 	github.com/alecthomas/mph v0.0.0-20190930022807-712982e3d8a2
-	github.com/alecthomas/unsafeslice v0.1.0 // indirect
+	github.com/alecthomas/unsafeslice v0.1.0
 	github.com/chzyer/logex v1.1.10 // indirect
 	github.com/chzyer/readline v0.0.0-20180603132655-2972be24d48e
 	github.com/chzyer/test v0.0.0-20180213035817-a1ea475d72b1 // indirect
@@ -0,0 +1,415 @@
+// Package inference tries to figure out what tiles the opponent kept after
+// their last play. It does this by drawing random racks that contain the
+// tiles they played, and only keeping the ones for which a static player
+// would have made the same play.
+package inference
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/domino14/macondo/ai/player"
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/movegen"
+)
+
+const (
+	// DefaultTolerance is how far (in equity) a play may be behind the
+	// best static play for us to still believe the opponent made it.
+	DefaultTolerance = 5.0
+	// DefaultSamples is the number of racks we try.
+	DefaultSamples = 2000
+)
+
+// ErrNoPlayToInferFrom is returned if the opponent's last turn was not a
+// tile placement or an exchange.
+var ErrNoPlayToInferFrom = errors.New("the opponent's last turn was not a play or an exchange")
+
+// InferredLeave is a leave the opponent may have kept after their last play.
+type InferredLeave struct {
+	Leave alphabet.MachineWord
+	// Count is the number of sampled racks that resulted in this leave.
+	Count int
+	// Probability is the posterior probability of this leave.
+	Probability float64
+}
+
+// Inference is the result of an inference; a distribution of the leaves
+// the opponent may have kept.
+type Inference struct {
+	// Leaves are sorted from most to least likely.
+	Leaves []*InferredLeave
+	// Samples is the number of racks that were tried, and Accepted is the
+	// number of those for which the opponent's play was a good static play.
+	Samples  int
+	Accepted int
+
+	alph *alphabet.Alphabet
+}
+
+// RandomLeave draws a leave from the inferred distribution. It returns nil
+// if nothing could be inferred.
+func (inf *Inference) RandomLeave(r *rand.Rand) alphabet.MachineWord {
+	if inf.Accepted == 0 {
+		return nil
+	}
+	n := r.Intn(inf.Accepted)
+	for _, l := range inf.Leaves {
+		if n < l.Count {
+			return l.Leave
+		}
+		n -= l.Count
+	}
+	return nil
+}
+
+// Summary returns a user-readable table of the n most likely leaves.
+func (inf *Inference) Summary(n int) string {
+	var ss strings.Builder
+	fmt.Fprintf(&ss, "%10v%10v\n", "Leave", "Prob %")
+	for idx, l := range inf.Leaves {
+		if idx >= n {
+			break
+		}
+		fmt.Fprintf(&ss, "%10v%10.2f\n", l.Leave.UserVisible(inf.alph), 100.0*l.Probability)
+	}
+	fmt.Fprintf(&ss, "Accepted %v of %v racks (%v distinct leaves)\n",
+		inf.Accepted, inf.Samples, len(inf.Leaves))
+	return ss.String()
+}
+
+// Inferrer infers the opponent's leave from their last play.
+type Inferrer struct {
+	origGame *game.Game
+	aiplayer player.AIPlayer
+
+	threads    int
+	tolerance  float64
+	numSamples int
+}
+
+func (i *Inferrer) Init(game *game.Game, aiplayer player.AIPlayer) {
+	i.origGame = game
+	i.aiplayer = aiplayer
+	i.threads = int(math.Max(1, float64(runtime.NumCPU()-1)))
+	i.tolerance = DefaultTolerance
+	i.numSamples = DefaultSamples
+}
+
+func (i *Inferrer) SetThreads(threads int) {
+	i.threads = threads
+}
+
+// SetTolerance sets how far behind the best static play (in equity) the
+// opponent's play may be for a rack to be accepted.
+func (i *Inferrer) SetTolerance(t float64) {
+	i.tolerance = t
+}
+
+// SetSamples sets the number of racks to try.
+func (i *Inferrer) SetSamples(n int) {
+	i.numSamples = n
+}
+
+// inferenceSetup is the position right before the opponent's last play.
+type inferenceSetup struct {
+	game    *game.Game
+	us, opp int
+	ourRack *alphabet.Rack
+	// played are the tiles the opponent played. For an exchange, this is
+	// empty, and numExchanged is set instead.
+	played       alphabet.MachineWord
+	event        *pb.GameEvent
+	numExchanged int
+	// pool is every tile the opponent could have had on their leave.
+	pool []alphabet.MachineLetter
+	// leaveSize is the number of tiles that were on the opponent's rack
+	// besides the ones they played.
+	leaveSize int
+}
+
+// Infer samples racks for the opponent's last play and returns the
+// distribution of leaves they may have kept.
+func (i *Inferrer) Infer(ctx context.Context) (*Inference, error) {
+	setup, err := i.setup()
+	if err != nil {
+		return nil, err
+	}
+	if setup.leaveSize == 0 && setup.numExchanged == 0 {
+		// They played out their whole rack; there is nothing to infer.
+		return NewInference([]alphabet.MachineWord{{}}, i.origGame.Alphabet()), nil
+	}
+	log.Debug().Int("threads", i.threads).Int("samples", i.numSamples).
+		Str("played", setup.played.UserVisible(i.origGame.Alphabet())).
+		Int("exchanged", setup.numExchanged).Int("leaveSize", setup.leaveSize).
+		Msg("inferring")
+
+	var tried int64
+	counts := make([]map[string]*InferredLeave, i.threads)
+	g := errgroup.Group{}
+	for t := 0; t < i.threads; t++ {
+		t := t
+		counts[t] = map[string]*InferredLeave{}
+		gameCopy := setup.game.Copy()
+		gen, err := movegen.NewGeneratorForGame(gameCopy)
+		if err != nil {
+			return nil, err
+		}
+		r := rand.New(rand.NewSource(rand.Int63()))
+		g.Go(func() error {
+			pool := append([]alphabet.MachineLetter(nil), setup.pool...)
+			for atomic.AddInt64(&tried, 1) <= int64(i.numSamples) {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				r.Shuffle(len(pool), func(a, b int) {
+					pool[a], pool[b] = pool[b], pool[a]
+				})
+				leave, ok, err := i.tryRack(setup, gameCopy, gen, pool[:setup.leaveSize])
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+				key := leave.UserVisible(gameCopy.Alphabet())
+				if il, ok := counts[t][key]; ok {
+					il.Count++
+				} else {
+					counts[t][key] = &InferredLeave{Leave: leave, Count: 1}
+				}
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	if err != nil {
+		return nil, err
+	}
+	return i.tally(counts), nil
+}
+
+func (i *Inferrer) tally(counts []map[string]*InferredLeave) *Inference {
+	inf := &Inference{Samples: i.numSamples, alph: i.origGame.Alphabet()}
+	merged := map[string]*InferredLeave{}
+	for _, c := range counts {
+		for key, il := range c {
+			inf.Accepted += il.Count
+			if m, ok := merged[key]; ok {
+				m.Count += il.Count
+			} else {
+				merged[key] = il
+			}
+		}
+	}
+	for _, il := range merged {
+		il.Probability = float64(il.Count) / float64(inf.Accepted)
+		inf.Leaves = append(inf.Leaves, il)
+	}
+	sort.Slice(inf.Leaves, func(a, b int) bool {
+		if inf.Leaves[a].Count == inf.Leaves[b].Count {
+			return inf.Leaves[a].Leave.String() < inf.Leaves[b].Leave.String()
+		}
+		return inf.Leaves[a].Count > inf.Leaves[b].Count
+	})
+	return inf
+}
+
+// tryRack gives the opponent the tiles they played plus the passed-in
+// leave, and checks whether their play is within tolerance of the best
+// static play with that rack. It returns the leave they would have kept.
+func (i *Inferrer) tryRack(setup *inferenceSetup, g *game.Game,
+	gen movegen.MoveGenerator, leave []alphabet.MachineLetter) (alphabet.MachineWord, bool, error) {
+
+	alph := g.Alphabet()
+	oppTiles := append(append([]alphabet.MachineLetter(nil), setup.played...), leave...)
+	racks := make([]*alphabet.Rack, 2)
+	racks[setup.us] = setup.ourRack.Copy()
+	racks[setup.opp] = alphabet.NewRack(alph)
+	racks[setup.opp].Set(oppTiles)
+	err := g.SetRacksForBoth(racks)
+	if err != nil {
+		return nil, false, err
+	}
+	gen.GenAll(racks[setup.opp], g.Bag().TilesRemaining() >= game.ExchangeLimit)
+	plays := gen.Plays()
+	i.aiplayer.AssignEquity(plays, g.Board(), g.Bag(), racks[setup.us])
+	best := i.aiplayer.BestPlay(plays)
+	if best == nil {
+		return nil, false, nil
+	}
+	var actual *move.Move
+	for _, p := range plays {
+		if !setup.matches(p) {
+			continue
+		}
+		if actual == nil || p.Equity() > actual.Equity() {
+			actual = p
+		}
+	}
+	if actual == nil || actual.Equity() < best.Equity()-i.tolerance {
+		return nil, false, nil
+	}
+	// Canonicalize the leave so that we can count it.
+	kept := alphabet.NewRack(alph)
+	if setup.numExchanged > 0 {
+		kept.Set(actual.Leave())
+	} else {
+		kept.Set(leave)
+	}
+	return kept.TilesOn(), true, nil
+}
+
+// matches returns true if the generated play is the opponent's actual play.
+// For exchanges, any exchange of the right number of tiles matches; the
+// caller picks the best one.
+func (s *inferenceSetup) matches(m *move.Move) bool {
+	if s.numExchanged > 0 {
+		return m.Action() == move.MoveTypeExchange && m.TilesPlayed() == s.numExchanged
+	}
+	if m.Action() != move.MoveTypePlay {
+		return false
+	}
+	row, col, vertical := m.CoordsAndVertical()
+	if row != int(s.event.Row) || col != int(s.event.Column) ||
+		vertical != (s.event.Direction == pb.GameEvent_VERTICAL) {
+		return false
+	}
+	evtTiles, err := alphabet.ToMachineWord(s.event.PlayedTiles, s.game.Alphabet())
+	if err != nil || len(evtTiles) != len(m.Tiles()) {
+		return false
+	}
+	for idx, ml := range m.Tiles() {
+		if ml == alphabet.PlayedThroughMarker {
+			continue
+		}
+		if ml != evtTiles[idx] {
+			return false
+		}
+	}
+	return true
+}
+
+// setup rebuilds the position right before the opponent's last play, from
+// the point of view of the player on turn.
+func (i *Inferrer) setup() (*inferenceSetup, error) {
+	history := i.origGame.History()
+	if history == nil {
+		return nil, errors.New("the game has no history")
+	}
+	us := i.origGame.PlayerOnTurn()
+	opp := (us + 1) % i.origGame.NumPlayers()
+	oppNick := history.Players[opp].Nickname
+
+	evtIdx := -1
+	for t := i.origGame.Turn() - 1; t >= 0 && evtIdx == -1; t-- {
+		evt := history.Events[t]
+		if evt.Nickname != oppNick {
+			break
+		}
+		switch evt.Type {
+		case pb.GameEvent_TILE_PLACEMENT_MOVE, pb.GameEvent_EXCHANGE:
+			evtIdx = t
+		case pb.GameEvent_CHALLENGE_BONUS:
+			// Our unsuccessful challenge doesn't say anything about
+			// their rack; keep looking.
+			continue
+		default:
+			return nil, ErrNoPlayToInferFrom
+		}
+	}
+	if evtIdx == -1 {
+		return nil, ErrNoPlayToInferFrom
+	}
+
+	// Replay the game on a copy, so that we don't mess with the original
+	// game or its history.
+	replay := i.origGame.Copy()
+	replay.SetHistory(proto.Clone(history).(*pb.GameHistory))
+	err := replay.PlayToTurn(evtIdx)
+	if err != nil {
+		return nil, err
+	}
+	replay.SetPlayerOnTurn(opp)
+
+	setup := &inferenceSetup{
+		game:    replay,
+		us:      us,
+		opp:     opp,
+		ourRack: i.origGame.RackFor(us).Copy(),
+		event:   history.Events[evtIdx],
+	}
+	alph := replay.Alphabet()
+
+	switch setup.event.Type {
+	case pb.GameEvent_TILE_PLACEMENT_MOVE:
+		tiles, err := alphabet.ToMachineWord(setup.event.PlayedTiles, alph)
+		if err != nil {
+			return nil, err
+		}
+		row, col := int(setup.event.Row), int(setup.event.Column)
+		for idx, ml := range tiles {
+			if setup.event.Direction == pb.GameEvent_VERTICAL {
+				row = int(setup.event.Row) + idx
+			} else {
+				col = int(setup.event.Column) + idx
+			}
+			if ml == alphabet.PlayedThroughMarker || !replay.Board().GetSquare(row, col).IsEmpty() {
+				continue
+			}
+			if ml.IsBlanked() {
+				ml = alphabet.BlankMachineLetter
+			}
+			setup.played = append(setup.played, ml)
+		}
+	case pb.GameEvent_EXCHANGE:
+		// We may only know how many tiles they exchanged.
+		n, err := strconv.Atoi(setup.event.Exchanged)
+		if err != nil {
+			n = len([]rune(setup.event.Exchanged))
+		}
+		setup.numExchanged = n
+	}
+
+	opponentRack := alphabet.NewRack(alph)
+	opponentRack.Set(setup.played)
+	racks := make([]*alphabet.Rack, 2)
+	racks[us] = setup.ourRack.Copy()
+	racks[opp] = opponentRack
+	err = replay.SetRacksForBoth(racks)
+	if err != nil {
+		return nil, fmt.Errorf("the opponent's play is not consistent with our rack: %v", err)
+	}
+	setup.pool = replay.Bag().Peek()
+	setup.leaveSize = game.RackTileLimit - len(setup.played)
+	if setup.leaveSize > len(setup.pool) {
+		setup.leaveSize = len(setup.pool)
+	}
+	return setup, nil
+}
+
+// NewInference creates an inference where each of the passed-in leaves is
+// equally likely.
+func NewInference(leaves []alphabet.MachineWord, alph *alphabet.Alphabet) *Inference {
+	inf := &Inference{alph: alph, Samples: len(leaves), Accepted: len(leaves)}
+	for _, l := range leaves {
+		inf.Leaves = append(inf.Leaves, &InferredLeave{Leave: l, Count: 1,
+			Probability: 1.0 / float64(len(leaves))})
+	}
+	return inf
+}
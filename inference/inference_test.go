@@ -0,0 +1,130 @@
+package inference
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/ai/player"
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cache"
+	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/gaddag"
+	"github.com/domino14/macondo/gaddagmaker"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/movegen"
+	"github.com/domino14/macondo/runner"
+	"github.com/domino14/macondo/strategy"
+)
+
+var DefaultConfig = config.DefaultConfig()
+
+func TestMain(m *testing.M) {
+	for _, lex := range []string{"NWL18"} {
+		gdgPath := filepath.Join(DefaultConfig.LexiconPath, "gaddag", lex+".gaddag")
+		if _, err := os.Stat(gdgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateGaddag(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.Rename("out.gaddag", gdgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// setUpOpeningPlay has the first player make their best play from a fixed
+// rack, and returns the game with the second player on turn.
+func setUpOpeningPlay(is *is.I) (*game.Game, player.AIPlayer, int) {
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	g, err := game.NewGame(rules, players)
+	is.NoErr(err)
+	strat, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		g.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+	aiplayer := player.NewRawEquityPlayer(strat)
+
+	gdObj, err := cache.Load(g.Config(), "gaddag:"+g.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), g.Board(),
+		rules.LetterDistribution())
+
+	g.StartGame()
+	g.SetPlayerOnTurn(0)
+	err = g.SetRackFor(0, alphabet.RackFromString("AEINRTZ", g.Alphabet()))
+	is.NoErr(err)
+	best := player.GenBestStaticTurn(g, generator, aiplayer, 0)
+	is.True(best != nil)
+	err = g.PlayMove(best, true, 0)
+	is.NoErr(err)
+	return g, aiplayer, best.TilesPlayed()
+}
+
+func TestInferEverythingAccepted(t *testing.T) {
+	is := is.New(t)
+	g, aiplayer, tilesPlayed := setUpOpeningPlay(is)
+
+	inferrer := &Inferrer{}
+	inferrer.Init(g, aiplayer)
+	inferrer.SetThreads(2)
+	inferrer.SetSamples(100)
+	// With a huge tolerance, every rack is consistent with the play.
+	inferrer.SetTolerance(1000)
+	inf, err := inferrer.Infer(context.Background())
+	is.NoErr(err)
+	is.Equal(inf.Samples, 100)
+	is.Equal(inf.Accepted, 100)
+
+	total := 0.0
+	for _, l := range inf.Leaves {
+		is.Equal(len(l.Leave), game.RackTileLimit-tilesPlayed)
+		total += l.Probability
+	}
+	is.True(math.Abs(total-1.0) < 1e-9)
+	is.True(inf.RandomLeave(rand.New(rand.NewSource(1))) != nil)
+}
+
+func TestInferNoPlay(t *testing.T) {
+	is := is.New(t)
+	g, aiplayer, _ := setUpOpeningPlay(is)
+	// Pretend the first player is on turn again. Their opponent has not
+	// made a play yet.
+	g.SetPlayerOnTurn(0)
+
+	inferrer := &Inferrer{}
+	inferrer.Init(g, aiplayer)
+	_, err := inferrer.Infer(context.Background())
+	is.Equal(err, ErrNoPlayToInferFrom)
+}
+
+func TestRandomLeave(t *testing.T) {
+	is := is.New(t)
+	alph := alphabet.EnglishAlphabet()
+	ab, _ := alphabet.ToMachineWord("AB", alph)
+	cd, _ := alphabet.ToMachineWord("CD", alph)
+	inf := NewInference([]alphabet.MachineWord{ab, cd}, alph)
+	r := rand.New(rand.NewSource(42))
+	seen := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		seen[inf.RandomLeave(r).UserVisible(alph)]++
+	}
+	is.Equal(len(seen), 2)
+	is.True(seen["AB"] > 400)
+	is.True(seen["CD"] > 400)
+
+	empty := &Inference{}
+	is.True(empty.RandomLeave(r) == nil)
+}
@@ -0,0 +1,250 @@
+package montecarlo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+)
+
+// CheckpointVersion is the version of the checkpoint format.
+const CheckpointVersion = 1
+
+// Checkpoint is the state of a simulation, which can be written to disk and
+// resumed later, possibly on another machine.
+type Checkpoint struct {
+	Version int `json:"version"`
+	// The position is the game history, played up to the given turn, with
+	// the given rack for the player we are simming for.
+	History *pb.GameHistory `json:"history"`
+	Turn    int             `json:"turn"`
+	Rack    string          `json:"rack"`
+	// KnownOppRack is set if we simmed with some of the opponent's tiles
+	// fixed.
+	KnownOppRack string `json:"known_opp_rack,omitempty"`
+
+	Plies      int               `json:"plies"`
+	Iterations int               `json:"iterations"`
+	Plays      []*CheckpointPlay `json:"plays"`
+}
+
+// CheckpointPlay is a candidate play, along with its statistics so far.
+type CheckpointPlay struct {
+	// Event is the play, in the same format as it would be in a history.
+	Event         *pb.GameEvent `json:"event"`
+	Equity        float64       `json:"equity"`
+	Ignore        bool          `json:"ignore,omitempty"`
+	ScoreStats    []Statistic   `json:"score_stats"`
+	BingoStats    []Statistic   `json:"bingo_stats"`
+	EquityStats   Statistic     `json:"equity_stats"`
+	LeftoverStats Statistic     `json:"leftover_stats"`
+	WinPCTStats   Statistic     `json:"winpct_stats"`
+}
+
+// Checkpoint returns the current state of the simulation. It is safe to
+// call while simming, although plays might have a few more iterations than
+// the returned iteration count.
+func (s *Simmer) Checkpoint() (*Checkpoint, error) {
+	if !s.readyToSim {
+		return nil, errors.New("there is no simulation to save")
+	}
+	g := s.origGame
+	cp := &Checkpoint{
+		Version: CheckpointVersion,
+		History: proto.Clone(g.History()).(*pb.GameHistory),
+		Turn:    g.Turn(),
+		Rack:    g.RackLettersFor(s.initialPlayer),
+		Plies:   s.maxPlies,
+	}
+	if s.knownOppRack != nil {
+		cp.KnownOppRack = s.knownOppRack.UserVisible(g.Alphabet())
+	}
+	s.iterMutex.Lock()
+	cp.Iterations = s.iterationCount
+	s.iterMutex.Unlock()
+
+	for _, sp := range s.plays {
+		sp.Lock()
+		cpp := &CheckpointPlay{
+			Event:         g.EventFromMove(sp.play),
+			Equity:        sp.play.Equity(),
+			Ignore:        sp.ignore,
+			ScoreStats:    append([]Statistic(nil), sp.scoreStats...),
+			BingoStats:    append([]Statistic(nil), sp.bingoStats...),
+			EquityStats:   sp.equityStats,
+			LeftoverStats: sp.leftoverStats,
+			WinPCTStats:   sp.winPCTStats,
+		}
+		sp.Unlock()
+		cp.Plays = append(cp.Plays, cpp)
+	}
+	return cp, nil
+}
+
+// WriteCheckpoint writes the current state of the simulation to w.
+func (s *Simmer) WriteCheckpoint(w io.Writer) error {
+	cp, err := s.Checkpoint()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cp)
+}
+
+// ReadCheckpoint reads a checkpoint that was written with WriteCheckpoint.
+func ReadCheckpoint(r io.Reader) (*Checkpoint, error) {
+	cp := &Checkpoint{}
+	err := json.NewDecoder(r).Decode(cp)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Version != CheckpointVersion {
+		return nil, fmt.Errorf("unsupported checkpoint version %v", cp.Version)
+	}
+	if cp.History == nil || len(cp.Plays) == 0 {
+		return nil, errors.New("checkpoint has no position or plays")
+	}
+	return cp, nil
+}
+
+// RestoreCheckpoint prepares the simulation to continue from the given
+// checkpoint. The simmer's game must already be set to the checkpoint's
+// position.
+func (s *Simmer) RestoreCheckpoint(cp *Checkpoint) error {
+	if s.simming {
+		return errors.New("please stop sim before restoring a checkpoint")
+	}
+	g := s.origGame
+	plays := make([]*move.Move, len(cp.Plays))
+	for idx, cpp := range cp.Plays {
+		m := game.MoveFromEvent(cpp.Event, g.Alphabet(), g.Board())
+		if m == nil {
+			return fmt.Errorf("could not restore play %v", cpp.Event.Position)
+		}
+		m.SetEquity(cpp.Equity)
+		plays[idx] = m
+	}
+	err := s.PrepareSim(cp.Plies, plays)
+	if err != nil {
+		return err
+	}
+	for idx, cpp := range cp.Plays {
+		if len(cpp.ScoreStats) != cp.Plies || len(cpp.BingoStats) != cp.Plies {
+			return errors.New("checkpoint plays do not have a statistic per ply")
+		}
+		sp := s.plays[idx]
+		copy(sp.scoreStats, cpp.ScoreStats)
+		copy(sp.bingoStats, cpp.BingoStats)
+		sp.equityStats = cpp.EquityStats
+		sp.leftoverStats = cpp.LeftoverStats
+		sp.winPCTStats = cpp.WinPCTStats
+		sp.ignore = cpp.Ignore
+	}
+	s.iterationCount = cp.Iterations
+	if cp.KnownOppRack != "" {
+		known, err := alphabet.ToMachineWord(cp.KnownOppRack, g.Alphabet())
+		if err != nil {
+			return err
+		}
+		return s.SetKnownOppRack(known)
+	}
+	return nil
+}
+
+// MergeCheckpoint adds the statistics of another simulation of the same
+// position and plays to this one. This way, the same position can be simmed
+// on several machines at once.
+func (s *Simmer) MergeCheckpoint(cp *Checkpoint) error {
+	if s.simming {
+		return errors.New("please stop sim before merging a checkpoint")
+	}
+	if !s.readyToSim {
+		return errors.New("please prepare the simulation first")
+	}
+	if cp.Plies != s.maxPlies {
+		return fmt.Errorf("cannot merge a %v-ply sim into a %v-ply sim", cp.Plies, s.maxPlies)
+	}
+	g := s.origGame
+	if !s.samePosition(cp) {
+		return errors.New("cannot merge a sim of a different position")
+	}
+	if !sameTiles(cp.Rack, g.RackLettersFor(s.initialPlayer), g.Alphabet()) {
+		return fmt.Errorf("cannot merge a sim with rack %v into a sim with rack %v",
+			cp.Rack, g.RackLettersFor(s.initialPlayer))
+	}
+	knownOppRack := ""
+	if s.knownOppRack != nil {
+		knownOppRack = s.knownOppRack.UserVisible(g.Alphabet())
+	}
+	if !sameTiles(cp.KnownOppRack, knownOppRack, g.Alphabet()) {
+		return fmt.Errorf("cannot merge a sim with known opponent tiles %q into one with %q",
+			cp.KnownOppRack, knownOppRack)
+	}
+	byDesc := map[string]*SimmedPlay{}
+	for _, sp := range s.plays {
+		byDesc[sp.play.ShortDescription()] = sp
+	}
+	toMerge := make([]*SimmedPlay, len(cp.Plays))
+	for idx, cpp := range cp.Plays {
+		m := game.MoveFromEvent(cpp.Event, g.Alphabet(), g.Board())
+		if m == nil {
+			return fmt.Errorf("could not restore play %v", cpp.Event.Position)
+		}
+		sp, ok := byDesc[m.ShortDescription()]
+		if !ok {
+			return fmt.Errorf("play %v is not being simmed", m.ShortDescription())
+		}
+		if len(cpp.ScoreStats) != s.maxPlies || len(cpp.BingoStats) != s.maxPlies {
+			return errors.New("checkpoint plays do not have a statistic per ply")
+		}
+		toMerge[idx] = sp
+	}
+	// Only merge once we know every play matches.
+	for idx, cpp := range cp.Plays {
+		sp := toMerge[idx]
+		for ply := 0; ply < s.maxPlies; ply++ {
+			sp.scoreStats[ply].Merge(&cpp.ScoreStats[ply])
+			sp.bingoStats[ply].Merge(&cpp.BingoStats[ply])
+		}
+		sp.equityStats.Merge(&cpp.EquityStats)
+		sp.leftoverStats.Merge(&cpp.LeftoverStats)
+		sp.winPCTStats.Merge(&cpp.WinPCTStats)
+		// A play that was pruned in either sim stays pruned.
+		sp.ignore = sp.ignore || cpp.Ignore
+	}
+	s.iterationCount += cp.Iterations
+	return nil
+}
+
+// samePosition returns whether the checkpoint is of the position we're
+// simming: the same lexicon, and the same events up to the same turn.
+func (s *Simmer) samePosition(cp *Checkpoint) bool {
+	g := s.origGame
+	his := g.History()
+	if cp.Turn != g.Turn() || cp.History.Lexicon != his.Lexicon ||
+		len(cp.History.Events) < cp.Turn || len(his.Events) < cp.Turn {
+		return false
+	}
+	for t := 0; t < cp.Turn; t++ {
+		if !proto.Equal(cp.History.Events[t], his.Events[t]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTiles returns whether the two user-visible racks have the same tiles,
+// in any order.
+func sameTiles(a, b string, alph *alphabet.Alphabet) bool {
+	ra := alphabet.RackFromString(a, alph)
+	rb := alphabet.RackFromString(b, alph)
+	return ra.Hashable() == rb.Hashable()
+}
@@ -0,0 +1,113 @@
+package montecarlo
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/endgame"
+	"github.com/domino14/macondo/endgame/alphabeta"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+)
+
+// simEndgameTableMB is the size of the transposition table of each
+// thread's endgame solver.
+const simEndgameTableMB = 2
+
+// SetEndgamePlies makes the simmer solve the endgame with the endgame
+// solver, searching at most the given number of plies, once the bag is
+// empty in a simmed line. The rest of the game is then played out with the
+// solver's moves, so that the line ends with an exact spread instead of a
+// leave value that means nothing with an empty bag. 0 turns this off.
+func (s *Simmer) SetEndgamePlies(plies int) {
+	s.endgamePlies = plies
+}
+
+// SetEndgameTimeLimit bounds the time of every endgame solve with the
+// alphabeta solver. The solver searches one ply deeper at a time, and once
+// the time is up, it uses the deepest search it finished. A limit of 0
+// means no limit.
+func (s *Simmer) SetEndgameTimeLimit(d time.Duration) {
+	s.endgameTimeLimit = d
+}
+
+// SetEndgameSolver sets the function that makes the endgame solver of each
+// thread. nil, the default, makes an alphabeta solver.
+func (s *Simmer) SetEndgameSolver(f endgame.NewSolverFunc) {
+	s.newEndgameSolver = f
+}
+
+// makeEndgameSolvers sets up an endgame solver for every thread, if we are
+// solving endgames. The solver needs to back up the game for every ply it
+// searches, on top of the backup of the play being simmed.
+func (s *Simmer) makeEndgameSolvers() error {
+	s.endgameSolvers = nil
+	if s.endgamePlies <= 0 {
+		return nil
+	}
+	newSolver := s.newEndgameSolver
+	if newSolver == nil {
+		newSolver = alphabeta.NewSolver
+	}
+	s.endgameSolvers = make([]endgame.Solver, len(s.gameCopies))
+	for t, g := range s.gameCopies {
+		g.SetStateStackLength(1 + s.endgamePlies)
+		solver := newSolver()
+		err := solver.Init(s.movegens[t], g)
+		if err != nil {
+			return err
+		}
+		if ab, ok := solver.(*alphabeta.Solver); ok {
+			ab.SetTimeLimit(s.endgameTimeLimit)
+			// The table is cleared for every solve, and there are many
+			// short solves, so keep it small.
+			ab.SetTranspositionTableMB(simEndgameTableMB)
+		}
+		s.endgameSolvers[t] = solver
+	}
+	return nil
+}
+
+// solveEndgame returns the best sequence the endgame solver finds for the
+// player on turn in the thread's game.
+func (s *Simmer) solveEndgame(thread int) []*move.Move {
+	g := s.gameCopies[thread]
+	solver := s.endgameSolvers[thread]
+	g.SetBackupMode(game.SimulationMode)
+	defer g.SetBackupMode(game.NoBackup)
+
+	_, seq, err := solver.Solve(context.Background(), s.endgamePlies)
+	if err != nil {
+		log.Error().Err(err).Msg("solving-endgame")
+		return nil
+	}
+	return seq
+}
+
+// playOutEndgame plays the rest of the game in the thread's game with the
+// endgame solver's moves. Plies before maxPlies are added to the play's
+// statistics, as usual.
+func (s *Simmer) playOutEndgame(simmedPlay *SimmedPlay, thread, ply int) {
+	g := s.gameCopies[thread]
+	for g.Playing() == pb.PlayState_PLAYING {
+		seq := s.solveEndgame(thread)
+		if len(seq) == 0 {
+			// This should not happen, but don't get stuck if it does.
+			onTurn := g.PlayerOnTurn()
+			seq = []*move.Move{s.bestStaticTurn(onTurn, thread)}
+		}
+		for _, m := range seq {
+			if g.Playing() != pb.PlayState_PLAYING {
+				break
+			}
+			g.PlayMove(m, false, 0)
+			if ply < s.maxPlies {
+				simmedPlay.addScoreStat(m, ply)
+			}
+			ply++
+		}
+	}
+}
@@ -8,20 +8,24 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"math/rand"
 	"runtime"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 
 	"github.com/domino14/macondo/ai/player"
-	"github.com/domino14/macondo/cache"
-	"github.com/domino14/macondo/gaddag"
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/endgame"
 	"github.com/domino14/macondo/game"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/inference"
 	"github.com/domino14/macondo/move"
 	"github.com/domino14/macondo/movegen"
+	"github.com/domino14/macondo/strategy"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v2"
 )
@@ -72,6 +76,9 @@ type SimmedPlay struct {
 	bingoStats    []Statistic
 	equityStats   Statistic
 	leftoverStats Statistic
+	winPCTStats   Statistic
+	// ignore is set when the play has been pruned; it is no longer simmed.
+	ignore bool
 }
 
 func (sp *SimmedPlay) String() string {
@@ -92,33 +99,92 @@ func (sp *SimmedPlay) addScoreStat(play *move.Move, ply int) {
 }
 
 func (sp *SimmedPlay) addEquityStat(spread int, leftover float64) {
+	sp.Lock()
+	defer sp.Unlock()
 	sp.equityStats.Push(float64(spread) + leftover)
 	sp.leftoverStats.Push(leftover)
 }
 
+func (sp *SimmedPlay) addWinPCTStat(winPCT float64) {
+	sp.Lock()
+	defer sp.Unlock()
+	sp.winPCTStats.Push(winPCT)
+}
+
+func (sp *SimmedPlay) isIgnored() bool {
+	sp.Lock()
+	defer sp.Unlock()
+	return sp.ignore
+}
+
 // Simmer implements the actual look-ahead search
 type Simmer struct {
 	origGame *game.Game
 
-	gameCopies []*game.Game
-	movegens   []movegen.MoveGenerator
+	gameCopies  []*game.Game
+	movegens    []movegen.MoveGenerator
+	randSources []*rand.Rand
 
 	aiplayer player.AIPlayer
+	// ownPlayer and oppPlayer pick the moves in the simulated plies, for
+	// us and for the opponent. If nil, the aiplayer picks them.
+	ownPlayer player.AIPlayer
+	oppPlayer player.AIPlayer
+	// plyPlayers are the players for every thread, indexed by player.
+	plyPlayers [][]player.AIPlayer
 
 	initialSpread int
 	maxPlies      int
 	// initialPlayer is the player for whom we are simming.
 	initialPlayer  int
 	iterationCount int
-	threads        int
+	// iterMutex protects the iteration count while simming.
+	iterMutex sync.Mutex
+	threads   int
 
 	simming    bool
 	readyToSim bool
 	plays      []*SimmedPlay
 
 	logStream io.Writer
+
+	stoppingCondition StoppingCondition
+	iterationLimit    int
+	timeLimit         time.Duration
+
+	// winPCTs is only set if we are also evaluating plays by their
+	// win percentage.
+	winPCTs    *strategy.WinPCTTable
+	sortMetric SortMetric
+
+	// oppInference, if set, is where we draw the opponent's leave from,
+	// instead of giving them a fully random rack.
+	oppInference *inference.Inference
+	// knownOppRack are tiles we know the opponent has. The rest of their
+	// rack is random.
+	knownOppRack alphabet.MachineWord
+
+	// If endgamePlies is set, the endgame is solved with at most that many
+	// plies once the bag is empty in a simmed line.
+	endgamePlies     int
+	endgameTimeLimit time.Duration
+	newEndgameSolver endgame.NewSolverFunc
+	endgameSolvers   []endgame.Solver
+
+	// If seeded is set, every iteration's draws are derived from the seed
+	// and the iteration number.
+	seeded bool
+	seed   int64
 }
 
+// SortMetric is the statistic that simmed plays are ranked by.
+type SortMetric int
+
+const (
+	SortByEquity SortMetric = iota
+	SortByWinPCT
+)
+
 func (s *Simmer) Init(game *game.Game, aiplayer player.AIPlayer) {
 	s.origGame = game
 	s.aiplayer = aiplayer
@@ -133,22 +199,165 @@ func (s *Simmer) SetLogStream(l io.Writer) {
 	s.logStream = l
 }
 
+// SetStoppingCondition sets the confidence level at which plays get pruned
+// and the simulation stops on its own. StopNone turns this off.
+func (s *Simmer) SetStoppingCondition(sc StoppingCondition) {
+	s.stoppingCondition = sc
+}
+
+// SetWinPCTTable turns on win percentage evaluation, using the given
+// table. Pass in nil to turn it off.
+func (s *Simmer) SetWinPCTTable(t *strategy.WinPCTTable) {
+	s.winPCTs = t
+}
+
+// SetSortMetric sets the statistic that plays are ranked by.
+func (s *Simmer) SetSortMetric(m SortMetric) {
+	s.sortMetric = m
+}
+
+// SetOppInference makes the simmer draw the opponent's rack from the
+// inferred leaves, filling the rest of the rack randomly. Pass in nil to
+// go back to fully random racks.
+func (s *Simmer) SetOppInference(inf *inference.Inference) {
+	s.oppInference = inf
+}
+
+// SetKnownOppRack fixes some of the opponent's tiles; the rest of their
+// rack is drawn randomly on every iteration. The tiles must be unseen from
+// the point of view of the player on turn. Known tiles take precedence over
+// an inferred leave. Pass in nil to go back to fully random racks.
+func (s *Simmer) SetKnownOppRack(tiles alphabet.MachineWord) error {
+	if len(tiles) == 0 {
+		s.knownOppRack = nil
+		return nil
+	}
+	if len(tiles) > game.RackTileLimit {
+		return fmt.Errorf("the opponent cannot have more than %v tiles", game.RackTileLimit)
+	}
+	opp := (s.origGame.PlayerOnTurn() + 1) % s.origGame.NumPlayers()
+	unseen := map[alphabet.MachineLetter]int{}
+	for _, ml := range s.origGame.Bag().Peek() {
+		unseen[ml]++
+	}
+	for _, ml := range s.origGame.RackFor(opp).TilesOn() {
+		unseen[ml]++
+	}
+	for _, ml := range tiles {
+		if ml.IsBlanked() {
+			ml = alphabet.BlankMachineLetter
+		}
+		unseen[ml]--
+		if unseen[ml] < 0 {
+			return fmt.Errorf("the opponent cannot have the tiles %v",
+				tiles.UserVisible(s.origGame.Alphabet()))
+		}
+	}
+	s.knownOppRack = tiles
+	return nil
+}
+
+// SetSeed makes the simulation reproducible. The tiles drawn in each
+// iteration only depend on the seed and the iteration number, so a sim
+// with the same seed and iteration limit gives the same results no matter
+// how many threads it runs on. Stopping conditions are checked while
+// threads are still running, so they can still make results differ.
+func (s *Simmer) SetSeed(seed int64) {
+	s.seeded = true
+	s.seed = seed
+}
+
+// ClearSeed goes back to unseeded, non-reproducible simulations.
+func (s *Simmer) ClearSeed() {
+	s.seeded = false
+}
+
+// Seed returns the simulation's seed, and whether it has one.
+func (s *Simmer) Seed() (int64, bool) {
+	return s.seed, s.seeded
+}
+
+// SetOwnPlayer sets the AIPlayer that picks our moves in the simulated
+// plies after the play being simmed. Pass in nil to use the simmer's own
+// player. The simmer's own player still evaluates the leftover tiles.
+func (s *Simmer) SetOwnPlayer(p player.AIPlayer) {
+	s.ownPlayer = p
+}
+
+// SetOppPlayer sets the AIPlayer that picks the opponent's moves in the
+// simulated plies, for example one that is not as good as we are, or that
+// uses different leave values. Pass in nil to use the simmer's own player.
+func (s *Simmer) SetOppPlayer(p player.AIPlayer) {
+	s.oppPlayer = p
+}
+
+// OwnPlayer returns the player that picks our moves in the simulated plies.
+func (s *Simmer) OwnPlayer() player.AIPlayer {
+	if s.ownPlayer != nil {
+		return s.ownPlayer
+	}
+	return s.aiplayer
+}
+
+// OppPlayer returns the player that picks the opponent's moves in the
+// simulated plies.
+func (s *Simmer) OppPlayer() player.AIPlayer {
+	if s.oppPlayer != nil {
+		return s.oppPlayer
+	}
+	return s.aiplayer
+}
+
+// makePlyPlayers sets up the players for every thread. Players that make
+// random choices get a copy per thread that uses the thread's random
+// source.
+func (s *Simmer) makePlyPlayers() {
+	perThread := func(p player.AIPlayer, thread int) player.AIPlayer {
+		if rp, ok := p.(player.RandomizedPlayer); ok {
+			return rp.WithRandSource(s.randSources[thread])
+		}
+		return p
+	}
+	s.plyPlayers = make([][]player.AIPlayer, len(s.gameCopies))
+	for t := range s.gameCopies {
+		players := make([]player.AIPlayer, s.gameCopies[t].NumPlayers())
+		for idx := range players {
+			if idx == s.initialPlayer {
+				players[idx] = perThread(s.OwnPlayer(), t)
+			} else {
+				players[idx] = perThread(s.OppPlayer(), t)
+			}
+		}
+		s.plyPlayers[t] = players
+	}
+}
+
+// SetIterationLimit stops the simulation after the given number of
+// iterations. A limit of 0 means no limit.
+func (s *Simmer) SetIterationLimit(n int) {
+	s.iterationLimit = n
+}
+
+// SetTimeLimit stops the simulation after it has run for the given
+// duration. A limit of 0 means no limit.
+func (s *Simmer) SetTimeLimit(d time.Duration) {
+	s.timeLimit = d
+}
+
 func (s *Simmer) makeGameCopies() error {
 	log.Debug().Int("threads", s.threads).Msg("makeGameCopies")
 	s.gameCopies = []*game.Game{}
 	s.movegens = []movegen.MoveGenerator{}
-
-	obj, err := cache.Load(s.origGame.Config(), "gaddag:"+s.origGame.LexiconName(),
-		gaddag.CacheLoadFunc)
-	if err != nil {
-		return err
-	}
+	s.randSources = []*rand.Rand{}
 
 	for i := 0; i < s.threads; i++ {
 		s.gameCopies = append(s.gameCopies, s.origGame.Copy())
-		s.movegens = append(s.movegens,
-			movegen.NewGordonGenerator(obj.(*gaddag.SimpleGaddag),
-				s.gameCopies[i].Board(), s.gameCopies[i].Bag().LetterDistribution()))
+		gen, err := movegen.NewGeneratorForGame(s.gameCopies[i])
+		if err != nil {
+			return err
+		}
+		s.movegens = append(s.movegens, gen)
+		s.randSources = append(s.randSources, rand.New(rand.NewSource(rand.Int63())))
 
 	}
 	return nil
@@ -181,6 +390,10 @@ func (s *Simmer) Reset() {
 	s.plays = nil
 	s.gameCopies = nil
 	s.readyToSim = false
+	// What we know about the opponent's rack only applies to the
+	// position it was set in.
+	s.oppInference = nil
+	s.knownOppRack = nil
 }
 
 // PrepareSim resets all the stats before a simulation.
@@ -190,6 +403,11 @@ func (s *Simmer) PrepareSim(plies int, plays []*move.Move) error {
 		return err
 	}
 	s.resetStats(plies, plays)
+	s.makePlyPlayers()
+	err = s.makeEndgameSolvers()
+	if err != nil {
+		return err
+	}
 	s.readyToSim = true
 	return nil
 }
@@ -214,10 +432,26 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 	// in another goroutine.
 	// protect the simmed play statistics with a mutex.
 	log.Debug().Msgf("Simulating with %v threads", s.threads)
+	// The players might have changed since the sim was prepared.
+	s.makePlyPlayers()
+	err := s.makeEndgameSolvers()
+	if err != nil {
+		return err
+	}
 	syncChan := make(chan bool, s.threads)
 	logChan := make(chan []byte)
 	done := make(chan bool)
 
+	// simCtx is cancelled either by the caller, or by the simmer itself
+	// once one of its stopping conditions is met.
+	simCtx, simCancel := context.WithCancel(ctx)
+	defer simCancel()
+	if s.timeLimit > 0 {
+		var timeCancel context.CancelFunc
+		simCtx, timeCancel = context.WithTimeout(simCtx, s.timeLimit)
+		defer timeCancel()
+	}
+
 	ctrl := errgroup.Group{}
 	writer := errgroup.Group{}
 
@@ -225,19 +459,13 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 		defer func() {
 			log.Debug().Msgf("Sim controller thread exiting")
 		}()
-		for {
-			select {
-			case <-ctx.Done():
-				log.Debug().Msgf("Context is done: %v", ctx.Err())
-				for t := 0; t < s.threads; t++ {
-					syncChan <- true
-				}
-				log.Debug().Msgf("Sent sync messages to children threads...")
-				return ctx.Err()
-			default:
-				// Do nothing
-			}
+		<-simCtx.Done()
+		log.Debug().Msgf("Context is done: %v", simCtx.Err())
+		for t := 0; t < s.threads; t++ {
+			syncChan <- true
 		}
+		log.Debug().Msgf("Sent sync messages to children threads...")
+		return simCtx.Err()
 	})
 
 	if s.logStream != nil {
@@ -259,7 +487,6 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 		})
 	}
 
-	var iterMutex sync.Mutex
 	g := errgroup.Group{}
 	for t := 0; t < s.threads; t++ {
 		t := t
@@ -270,12 +497,22 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 			log.Debug().Msgf("Thread %v starting sim", t)
 			for {
 
-				iterMutex.Lock()
+				s.iterMutex.Lock()
+				if s.iterationLimit > 0 && s.iterationCount >= s.iterationLimit {
+					s.iterMutex.Unlock()
+					log.Debug().Msgf("Thread %v reached iteration limit", t)
+					simCancel()
+					return nil
+				}
 				iterNum := s.iterationCount + 1
 				s.iterationCount++
-				iterMutex.Unlock()
+				s.iterMutex.Unlock()
 
 				s.simSingleIteration(s.maxPlies, t, iterNum, logChan)
+				if iterNum%StoppingCheckInterval == 0 && s.pruneAndCheckForStop() {
+					log.Debug().Msgf("Thread %v found a statistically significant winner", t)
+					simCancel()
+				}
 				select {
 				case v := <-syncChan:
 					log.Debug().Msgf("Thread %v got sync msg %v", t, v)
@@ -288,7 +525,7 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 	}
 
 	// Wait for threads in errgroup:
-	err := g.Wait()
+	err = g.Wait()
 	log.Debug().Msgf("errgroup returned err %v", err)
 
 	// Writer thread will exit now:
@@ -299,6 +536,10 @@ func (s *Simmer) Simulate(ctx context.Context) error {
 
 	ctrlErr := ctrl.Wait()
 	log.Debug().Msgf("ctrl errgroup returned err %v", ctrlErr)
+	if ctx.Err() == nil {
+		// The caller did not stop us; we stopped on our own.
+		return nil
+	}
 	return ctrlErr
 }
 
@@ -321,13 +562,26 @@ func (s *Simmer) simSingleIteration(plies, thread, iterationCount int, logChan c
 	// Give opponent a random rack from the bag. Note that this also
 	// shuffles the bag!
 	opp := (s.initialPlayer + 1) % s.gameCopies[thread].NumPlayers()
-	s.gameCopies[thread].SetRandomRack(opp)
+	var playSeed int64
+	if s.seeded {
+		// Start every iteration from the same bag, no matter what the
+		// thread simmed before.
+		iterSeed := iterationSeed(s.seed, iterationCount)
+		s.gameCopies[thread].ThrowRackIn(opp)
+		s.gameCopies[thread].Bag().Reseed(iterSeed)
+		s.randSources[thread].Seed(iterSeed)
+		playSeed = iterationSeed(iterSeed, 0)
+	}
+	s.setOppRack(thread, opp)
 	logIter := LogIteration{Iteration: iterationCount, Plays: []LogPlay{}, Thread: thread}
 
 	var logPlay LogPlay
 	var plyChild LogPlay
 
 	for _, simmedPlay := range s.plays {
+		if simmedPlay.isIgnored() {
+			continue
+		}
 		if s.logStream != nil {
 			logPlay = LogPlay{Play: simmedPlay.play.ShortDescription(),
 				Rack: simmedPlay.play.FullRack(),
@@ -338,6 +592,12 @@ func (s *Simmer) simSingleIteration(plies, thread, iterationCount int, logChan c
 		// logIter.Plays = append(logIter.Plays)
 		// Play the move, and back up the game state.
 		// log.Debug().Msgf("Playing move %v", play)'
+		if s.seeded {
+			// Every play sees the same draws in this iteration, even if
+			// other plays have been pruned.
+			s.gameCopies[thread].Bag().Reseed(playSeed)
+			s.randSources[thread].Seed(iterationSeed(playSeed, 0))
+		}
 		// Set the backup mode to simulation mode only to back up the first move:
 		s.gameCopies[thread].SetBackupMode(game.SimulationMode)
 		s.gameCopies[thread].PlayMove(simmedPlay.play, false, 0)
@@ -346,6 +606,14 @@ func (s *Simmer) simSingleIteration(plies, thread, iterationCount int, logChan c
 		for ply := 0; ply < plies; ply++ {
 			// Each ply is a player taking a turn
 			onTurn := s.gameCopies[thread].PlayerOnTurn()
+			if s.endgameSolvers != nil && s.gameCopies[thread].Playing() == pb.PlayState_PLAYING &&
+				s.gameCopies[thread].Bag().TilesRemaining() == 0 {
+				// The rest of the game is solved exactly, so there are no
+				// leftover tiles to value.
+				s.playOutEndgame(simmedPlay, thread, ply)
+				leftover = 0
+				break
+			}
 			if s.gameCopies[thread].Playing() == pb.PlayState_PLAYING {
 				// Assume there are exactly two players.
 
@@ -378,7 +646,11 @@ func (s *Simmer) simSingleIteration(plies, thread, iterationCount int, logChan c
 		}
 		// log.Debug().Msgf("Spread for initial player: %v, leftover: %v",
 		// 	s.game.SpreadFor(s.initialPlayer), leftover)
-		simmedPlay.addEquityStat(s.gameCopies[thread].SpreadFor(s.initialPlayer)-s.initialSpread, leftover)
+		spread := s.gameCopies[thread].SpreadFor(s.initialPlayer)
+		simmedPlay.addEquityStat(spread-s.initialSpread, leftover)
+		if s.winPCTs != nil {
+			simmedPlay.addWinPCTStat(s.winPCT(thread, spread, leftover))
+		}
 		s.gameCopies[thread].ResetToFirstState()
 		logIter.Plays = append(logIter.Plays, logPlay)
 	}
@@ -392,8 +664,57 @@ func (s *Simmer) simSingleIteration(plies, thread, iterationCount int, logChan c
 	}
 }
 
+// setOppRack gives the opponent a random rack, taking into account what
+// we know or inferred about it, if anything.
+func (s *Simmer) setOppRack(thread, opp int) {
+	g := s.gameCopies[thread]
+	var known alphabet.MachineWord
+	switch {
+	case s.knownOppRack != nil:
+		known = s.knownOppRack
+	case s.oppInference != nil:
+		known = s.oppInference.RandomLeave(s.randSources[thread])
+	default:
+		g.SetRandomRack(opp)
+		return
+	}
+	err := g.SetPartialRack(opp, known)
+	if err != nil {
+		log.Debug().Err(err).Msg("could-not-set-partial-rack")
+	}
+}
+
+// iterationSeed mixes the seed and the iteration number into a new seed
+// (this is the SplitMix64 finalizer), so that nearby iterations do not get
+// nearby seeds.
+func iterationSeed(seed int64, iteration int) int64 {
+	z := uint64(seed) + uint64(iteration)*0x9e3779b97f4a7c15
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return int64(z ^ (z >> 31))
+}
+
+// winPCT estimates the probability that the initial player wins the game
+// from the final position of a simmed line.
+func (s *Simmer) winPCT(thread, spread int, leftover float64) float64 {
+	g := s.gameCopies[thread]
+	if g.Playing() == pb.PlayState_GAME_OVER {
+		if spread > 0 {
+			return 1.0
+		} else if spread == 0 {
+			return 0.5
+		}
+		return 0.0
+	}
+	opp := (s.initialPlayer + 1) % g.NumPlayers()
+	// Tiles unseen from the initial player's point of view.
+	unseen := g.Bag().TilesRemaining() + int(g.RackFor(opp).NumTiles())
+	return s.winPCTs.WinPCT(float64(spread)+leftover, unseen)
+}
+
 func (s *Simmer) bestStaticTurn(playerID, thread int) *move.Move {
-	return player.GenBestStaticTurn(s.gameCopies[thread], s.movegens[thread], s.aiplayer, playerID)
+	return player.GenBestStaticTurn(s.gameCopies[thread], s.movegens[thread],
+		s.plyPlayers[thread][playerID], playerID)
 }
 
 func (s *Simmer) sortPlaysByEquity() {
@@ -404,6 +725,23 @@ func (s *Simmer) sortPlaysByEquity() {
 	})
 }
 
+func (s *Simmer) sortPlaysByWinPCT() {
+	sort.Slice(s.plays, func(i, j int) bool {
+		if s.plays[i].winPCTStats.Mean() == s.plays[j].winPCTStats.Mean() {
+			return s.plays[i].equityStats.Mean() > s.plays[j].equityStats.Mean()
+		}
+		return s.plays[i].winPCTStats.Mean() > s.plays[j].winPCTStats.Mean()
+	})
+}
+
+func (s *Simmer) sortPlays() {
+	if s.sortMetric == SortByWinPCT && s.winPCTs != nil {
+		s.sortPlaysByWinPCT()
+		return
+	}
+	s.sortPlaysByEquity()
+}
+
 func (s *Simmer) printStats() string {
 	return s.EquityStats() + "\n Details per play \n" + s.ScoreDetails()
 }
@@ -411,12 +749,24 @@ func (s *Simmer) printStats() string {
 func (s *Simmer) EquityStats() string {
 	stats := ""
 
-	s.sortPlaysByEquity()
-	stats += fmt.Sprintf("%20v%6v%8v\n", "Play", "Score", "Equity")
+	s.sortPlays()
+	stats += fmt.Sprintf("%20v%6v%8v", "Play", "Score", "Equity")
+	if s.winPCTs != nil {
+		stats += fmt.Sprintf("%8v", "Win%")
+	}
+	stats += "\n"
 
 	for _, play := range s.plays {
-		stats += fmt.Sprintf("%20v%6d%8.3f\n", play.play.ShortDescription(),
+		pruned := ""
+		if play.ignore {
+			pruned = " (pruned)"
+		}
+		stats += fmt.Sprintf("%20v%6d%8.3f", play.play.ShortDescription(),
 			play.play.Score(), play.equityStats.Mean())
+		if s.winPCTs != nil {
+			stats += fmt.Sprintf("%8.2f", 100.0*play.winPCTStats.Mean())
+		}
+		stats += pruned + "\n"
 	}
 	stats += fmt.Sprintf("Iterations: %v\n", s.iterationCount)
 	return stats
@@ -424,7 +774,7 @@ func (s *Simmer) EquityStats() string {
 
 func (s *Simmer) ScoreDetails() string {
 	stats := ""
-	s.sortPlaysByEquity()
+	s.sortPlays()
 	for ply := 0; ply < s.maxPlies; ply++ {
 		who := "You"
 		if ply%2 == 0 {
@@ -1,8 +1,11 @@
 package montecarlo
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"testing"
@@ -19,6 +22,8 @@ import (
 	"github.com/domino14/macondo/gaddagmaker"
 	"github.com/domino14/macondo/game"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/inference"
+	"github.com/domino14/macondo/move"
 	"github.com/domino14/macondo/movegen"
 	"github.com/domino14/macondo/runner"
 	"github.com/domino14/macondo/strategy"
@@ -52,7 +57,7 @@ func TestSimSingleIteration(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard,
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
 		"NWL18", "English")
 	is.NoErr(err)
 	game, err := game.NewGame(rules, players)
@@ -96,6 +101,377 @@ func TestSimSingleIteration(t *testing.T) {
 	fmt.Println(simmer.printStats())
 }
 
+func TestSimWithOppRackKnowledge(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:10]
+	simmer := &Simmer{}
+	simmer.Init(game, player.NewRawEquityPlayer(strategy))
+	simmer.SetThreads(1)
+	simmer.PrepareSim(plies, plays)
+
+	qu, err := alphabet.ToMachineWord("QU", game.Alphabet())
+	is.NoErr(err)
+	simmer.SetOppInference(inference.NewInference([]alphabet.MachineWord{qu},
+		game.Alphabet()))
+	simmer.simSingleIteration(plies, 0, 1, nil)
+
+	// The opponent's rack is restored to what it was at the start of the
+	// iteration, which must have had the inferred leave on it.
+	oppRack := simmer.gameCopies[0].RackFor(1)
+	is.Equal(oppRack.NumTiles(), uint8(7))
+	is.True(oppRack.Has(qu[0]))
+	is.True(oppRack.Has(qu[1]))
+
+	// Known tiles take precedence over the inference.
+	zz, err := alphabet.ToMachineWord("ZZ", game.Alphabet())
+	is.NoErr(err)
+	is.True(simmer.SetKnownOppRack(zz) != nil)
+	zblank, err := alphabet.ToMachineWord("Z?", game.Alphabet())
+	is.NoErr(err)
+	is.NoErr(simmer.SetKnownOppRack(zblank))
+	simmer.simSingleIteration(plies, 0, 2, nil)
+	oppRack = simmer.gameCopies[0].RackFor(1)
+	is.Equal(oppRack.NumTiles(), uint8(7))
+	is.True(oppRack.Has(zblank[0]))
+	is.True(oppRack.Has(alphabet.BlankMachineLetter))
+
+	// Both are forgotten when the simmer is reset.
+	simmer.Reset()
+	is.True(simmer.oppInference == nil)
+	is.True(simmer.knownOppRack == nil)
+}
+
+func TestCheckpoint(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:5]
+	aiplayer := player.NewRawEquityPlayer(strategy)
+	simmer := &Simmer{}
+	simmer.Init(game, aiplayer)
+	simmer.SetThreads(1)
+	simmer.PrepareSim(plies, plays)
+	for i := 1; i <= 5; i++ {
+		simmer.simSingleIteration(plies, 0, i, nil)
+	}
+	simmer.iterationCount = 5
+
+	var buf bytes.Buffer
+	is.NoErr(simmer.WriteCheckpoint(&buf))
+	cp, err := ReadCheckpoint(&buf)
+	is.NoErr(err)
+	is.Equal(cp.Iterations, 5)
+	is.Equal(cp.Rack, "AAADERW")
+	is.Equal(len(cp.Plays), 5)
+
+	restored := &Simmer{}
+	restored.Init(game, aiplayer)
+	restored.SetThreads(1)
+	is.NoErr(restored.RestoreCheckpoint(cp))
+	is.Equal(restored.Iterations(), 5)
+	for idx, sp := range restored.plays {
+		orig := simmer.plays[idx]
+		is.Equal(sp.play.ShortDescription(), orig.play.ShortDescription())
+		is.Equal(sp.play.Score(), orig.play.Score())
+		is.Equal(sp.equityStats.Iterations(), 5)
+		is.True(fuzzyEqual(sp.equityStats.Mean(), orig.equityStats.Mean()))
+		is.True(fuzzyEqual(sp.scoreStats[1].Stdev(), orig.scoreStats[1].Stdev()))
+	}
+	// The restored sim can keep going.
+	restored.simSingleIteration(plies, 0, 6, nil)
+	is.Equal(restored.plays[0].equityStats.Iterations(), 6)
+
+	// Merging the original sim in adds its iterations.
+	is.NoErr(restored.MergeCheckpoint(cp))
+	is.Equal(restored.Iterations(), 10)
+	is.Equal(restored.plays[0].equityStats.Iterations(), 11)
+
+	// Sims of another position can't be merged.
+	cp.Rack = "AAADERZ"
+	is.True(restored.MergeCheckpoint(cp) != nil)
+	cp.Rack = "WREDAAA"
+	cp.KnownOppRack = "Q"
+	is.True(restored.MergeCheckpoint(cp) != nil)
+	cp.KnownOppRack = ""
+	cp.Turn = 1
+	is.True(restored.MergeCheckpoint(cp) != nil)
+	cp.Turn = 0
+	is.Equal(restored.Iterations(), 10)
+
+	// Plays pruned in the merged sim stay pruned.
+	last := len(cp.Plays) - 1
+	cp.Plays[last].Ignore = true
+	is.NoErr(restored.MergeCheckpoint(cp))
+	is.Equal(restored.Iterations(), 15)
+	is.True(restored.plays[last].ignore)
+}
+
+func TestResults(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:5]
+	simmer := &Simmer{}
+	simmer.Init(game, player.NewRawEquityPlayer(strategy))
+	simmer.SetThreads(1)
+	simmer.PrepareSim(plies, plays)
+	for i := 1; i <= 10; i++ {
+		simmer.simSingleIteration(plies, 0, i, nil)
+	}
+	simmer.iterationCount = 10
+
+	res := simmer.Results()
+	is.Equal(res.Iterations, int32(10))
+	is.Equal(res.Plies, int32(plies))
+	is.Equal(res.ZValue, DefaultZValue)
+	is.Equal(len(res.Plays), 5)
+	for idx, p := range res.Plays {
+		if idx > 0 {
+			is.True(p.Equity.Mean <= res.Plays[idx-1].Equity.Mean)
+		}
+		is.Equal(p.Equity.Iterations, int32(10))
+		is.True(p.Equity.CiLow <= p.Equity.Mean)
+		is.True(p.Equity.CiHigh >= p.Equity.Mean)
+		is.True(p.WinPct == nil)
+		is.Equal(len(p.PlyStats), plies)
+		is.Equal(p.PlyStats[0].Ply, int32(1))
+		is.Equal(p.Move.Type, pb.GameEvent_TILE_PLACEMENT_MOVE)
+	}
+}
+
+func TestSeededSim(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:5]
+
+	sim := func(threads int, seed int64) *Simmer {
+		simmer := &Simmer{}
+		simmer.Init(game, player.NewRawEquityPlayer(strategy))
+		simmer.SetThreads(threads)
+		simmer.SetSeed(seed)
+		simmer.SetIterationLimit(50)
+		is.NoErr(simmer.PrepareSim(plies, plays))
+		is.NoErr(simmer.Simulate(context.Background()))
+		is.Equal(simmer.Iterations(), 50)
+		return simmer
+	}
+	one := sim(1, 42)
+	three := sim(3, 42)
+	other := sim(1, 43)
+	differs := false
+	for idx, sp := range one.plays {
+		// The stats are pushed in a different order, so allow for some
+		// floating point error.
+		is.True(math.Abs(sp.equityStats.Mean()-three.plays[idx].equityStats.Mean()) < 1e-9)
+		is.True(math.Abs(sp.equityStats.Stdev()-three.plays[idx].equityStats.Stdev()) < 1e-9)
+		if sp.equityStats.Mean() != other.plays[idx].equityStats.Mean() {
+			differs = true
+		}
+	}
+	is.True(differs)
+}
+
+func TestSimWithOppPlayer(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:5]
+
+	raw := player.NewRawEquityPlayer(strategy)
+	club := player.NewTopNPlayer(strategy, 5, rand.New(rand.NewSource(1)))
+	sim := func(threads int) *Simmer {
+		simmer := &Simmer{}
+		simmer.Init(game, raw)
+		simmer.SetOppPlayer(club)
+		simmer.SetThreads(threads)
+		simmer.SetSeed(42)
+		simmer.SetIterationLimit(30)
+		is.NoErr(simmer.PrepareSim(plies, plays))
+		is.NoErr(simmer.Simulate(context.Background()))
+		return simmer
+	}
+	one := sim(1)
+	two := sim(2)
+	// We play with our own player, and every thread gets its own copy of
+	// the opponent, as it makes random choices.
+	is.Equal(two.plyPlayers[0][0], raw)
+	is.Equal(two.plyPlayers[1][0], raw)
+	is.True(two.plyPlayers[0][1] != two.plyPlayers[1][1])
+	is.True(two.plyPlayers[0][1] != club)
+	// The opponent's choices are seeded too.
+	for idx, sp := range one.plays {
+		is.True(math.Abs(sp.equityStats.Mean()-two.plays[idx].equityStats.Mean()) < 1e-9)
+	}
+}
+
+func TestSimWithEndgame(t *testing.T) {
+	is := is.New(t)
+	plies := 2
+
+	players := []*pb.PlayerInfo{
+		{Nickname: "JD", RealName: "Jesse"},
+		{Nickname: "cesar", RealName: "César"},
+	}
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
+		"NWL18", "English")
+	is.NoErr(err)
+	game, err := game.NewGame(rules, players)
+	is.NoErr(err)
+
+	strategy, err := strategy.NewExhaustiveLeaveStrategy(rules.LexiconName(),
+		game.Alphabet(), &DefaultConfig, strategy.LeaveFilename, strategy.PEGAdjustmentFilename)
+	is.NoErr(err)
+
+	gdObj, err := cache.Load(game.Config(), "gaddag:"+game.LexiconName(), gaddag.CacheLoadFunc)
+	is.NoErr(err)
+	generator := movegen.NewGordonGenerator(gdObj.(*gaddag.SimpleGaddag), game.Board(),
+		rules.LetterDistribution())
+
+	game.StartGame()
+	game.SetPlayerOnTurn(0)
+	game.SetRackFor(0, alphabet.RackFromString("AAADERW", game.Alphabet()))
+	// Leave a single tile in the bag, so that any play empties it.
+	game.Bag().DrawAtMost(game.Bag().TilesRemaining() - 1)
+	generator.GenAll(game.RackFor(0), false)
+	plays := generator.Plays()[:2]
+
+	simmer := &Simmer{}
+	simmer.Init(game, player.NewRawEquityPlayer(strategy))
+	simmer.SetThreads(1)
+	simmer.SetEndgamePlies(1)
+	is.NoErr(simmer.PrepareSim(plies, plays))
+	for i := 1; i <= 3; i++ {
+		simmer.simSingleIteration(plies, 0, i, nil)
+	}
+	for _, sp := range simmer.plays {
+		// Every line was played out to the end, so there is no leftover.
+		is.Equal(sp.equityStats.Iterations(), 3)
+		is.Equal(sp.leftoverStats.Mean(), 0.0)
+		is.Equal(sp.scoreStats[0].Iterations(), 3)
+	}
+	// The original game shouldn't change at all.
+	is.True(game.Board().IsEmpty())
+	is.Equal(game.Bag().TilesRemaining(), 1)
+}
+
 func TestLongerSim(t *testing.T) {
 	// t.Skip()
 	is := is.New(t)
@@ -105,7 +481,7 @@ func TestLongerSim(t *testing.T) {
 		{Nickname: "JD", RealName: "Jesse"},
 		{Nickname: "cesar", RealName: "César"},
 	}
-	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard,
+	rules, err := runner.NewAIGameRules(&DefaultConfig, board.CrosswordGameBoard, game.VariantCrosswordGame,
 		"NWL18", "English")
 	is.NoErr(err)
 	game, err := game.NewGame(rules, players)
@@ -156,6 +532,40 @@ func TestLongerSim(t *testing.T) {
 	is.Equal(simmer.gameCopies[0].Turn(), 0)
 }
 
+func TestPruneAndCheckForStop(t *testing.T) {
+	is := is.New(t)
+	alph := alphabet.EnglishAlphabet()
+	simmer := &Simmer{}
+	simmer.SetStoppingCondition(Stop99)
+	for i := 0; i < 3; i++ {
+		sp := &SimmedPlay{play: move.NewPassMove(alphabet.MachineWord{}, alph)}
+		simmer.plays = append(simmer.plays, sp)
+	}
+	// Not enough iterations yet.
+	simmer.plays[0].addEquityStat(30, 0)
+	simmer.plays[1].addEquityStat(20, 0)
+	simmer.plays[2].addEquityStat(0, 0)
+	is.True(!simmer.pruneAndCheckForStop())
+	is.True(!simmer.plays[2].ignore)
+
+	for i := 0; i < MinPruningIterations; i++ {
+		// The first two plays are close; the third is hopeless.
+		simmer.plays[0].addEquityStat(30+i%20, 0)
+		simmer.plays[1].addEquityStat(29+i%20, 0)
+		simmer.plays[2].addEquityStat(-10+i%20, 0)
+	}
+	is.True(!simmer.pruneAndCheckForStop())
+	is.True(!simmer.plays[0].ignore)
+	is.True(!simmer.plays[1].ignore)
+	is.True(simmer.plays[2].ignore)
+
+	for i := 0; i < MinPruningIterations; i++ {
+		simmer.plays[1].addEquityStat(0, 0)
+	}
+	is.True(simmer.pruneAndCheckForStop())
+	is.True(simmer.plays[1].ignore)
+}
+
 // func TestDrawingAssumptions(t *testing.T) {
 // 	// Test that we are actually drawing from a sane bag.
 // 	// is := is.New(t)
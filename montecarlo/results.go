@@ -0,0 +1,70 @@
+package montecarlo
+
+import (
+	"sort"
+
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+)
+
+// DefaultZValue is the z-score that confidence intervals are computed with
+// if the sim has no stopping condition; it corresponds to 95% confidence.
+const DefaultZValue = 1.96
+
+func statResults(st *Statistic, zval float64) *pb.SimStat {
+	se := st.StandardError(zval)
+	return &pb.SimStat{
+		Mean:       st.Mean(),
+		Stdev:      st.Stdev(),
+		CiLow:      st.Mean() - se,
+		CiHigh:     st.Mean() + se,
+		Iterations: int32(st.Iterations()),
+	}
+}
+
+// Results returns the results of the simulation so far, with the plays
+// ranked the same way as in EquityStats. It is safe to call while simming.
+func (s *Simmer) Results() *pb.SimResults {
+	zval := DefaultZValue
+	if z, ok := zValues[s.stoppingCondition]; ok {
+		zval = z
+	}
+	res := &pb.SimResults{Plies: int32(s.maxPlies), ZValue: zval}
+	s.iterMutex.Lock()
+	res.Iterations = int32(s.iterationCount)
+	s.iterMutex.Unlock()
+
+	for _, sp := range s.plays {
+		sp.Lock()
+		ps := &pb.SimmedPlayStats{
+			Move:         s.origGame.EventFromMove(sp.play),
+			Description:  sp.play.ShortDescription(),
+			Score:        int32(sp.play.Score()),
+			StaticEquity: sp.play.Equity(),
+			Equity:       statResults(&sp.equityStats, zval),
+			Leftover:     statResults(&sp.leftoverStats, zval),
+			Pruned:       sp.ignore,
+		}
+		if s.winPCTs != nil {
+			ps.WinPct = statResults(&sp.winPCTStats, zval)
+		}
+		for ply := range sp.scoreStats {
+			ps.PlyStats = append(ps.PlyStats, &pb.PlyStats{
+				Ply:   int32(ply + 1),
+				Score: statResults(&sp.scoreStats[ply], zval),
+				Bingo: statResults(&sp.bingoStats[ply], zval),
+			})
+		}
+		sp.Unlock()
+		res.Plays = append(res.Plays, ps)
+	}
+
+	byWinPCT := s.sortMetric == SortByWinPCT && s.winPCTs != nil
+	sort.SliceStable(res.Plays, func(i, j int) bool {
+		pi, pj := res.Plays[i], res.Plays[j]
+		if byWinPCT && pi.WinPct.Mean != pj.WinPct.Mean {
+			return pi.WinPct.Mean > pj.WinPct.Mean
+		}
+		return pi.Equity.Mean > pj.Equity.Mean
+	})
+	return res
+}
@@ -1,6 +1,9 @@
 package montecarlo
 
-import "math"
+import (
+	"encoding/json"
+	"math"
+)
 
 // Statistic contains statistics per move
 type Statistic struct {
@@ -44,3 +47,63 @@ func (s *Statistic) Variance() float64 {
 func (s *Statistic) Stdev() float64 {
 	return math.Sqrt(s.Variance())
 }
+
+// Iterations returns the number of values that have been pushed.
+func (s *Statistic) Iterations() int {
+	return s.totalIterations
+}
+
+// StandardError returns the standard error of the mean, multiplied by the
+// given z-value. With a z-value of 1.96, for example, the mean plus or minus
+// this value is the 95% confidence interval.
+func (s *Statistic) StandardError(zval float64) float64 {
+	if s.totalIterations <= 1 {
+		return 0.0
+	}
+	return zval * math.Sqrt(s.Variance()/float64(s.totalIterations))
+}
+
+// Merge adds the values that were pushed into another statistic to this
+// one, as if they had been pushed here. It uses Chan et al.'s parallel
+// variant of Welford's algorithm.
+func (s *Statistic) Merge(other *Statistic) {
+	if other.totalIterations == 0 {
+		return
+	}
+	if s.totalIterations == 0 {
+		*s = *other
+		return
+	}
+	n1 := float64(s.totalIterations)
+	n2 := float64(other.totalIterations)
+	delta := other.newM - s.newM
+	s.totalIterations += other.totalIterations
+	s.newM = s.newM + delta*n2/(n1+n2)
+	s.newS = s.newS + other.newS + delta*delta*n1*n2/(n1+n2)
+	s.oldM = s.newM
+	s.oldS = s.newS
+}
+
+// statisticJSON is the serialized form of a Statistic. M2 is the sum of
+// squared differences from the mean.
+type statisticJSON struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	M2   float64 `json:"m2"`
+}
+
+func (s Statistic) MarshalJSON() ([]byte, error) {
+	return json.Marshal(statisticJSON{N: s.totalIterations, Mean: s.newM, M2: s.newS})
+}
+
+func (s *Statistic) UnmarshalJSON(data []byte) error {
+	var sj statisticJSON
+	err := json.Unmarshal(data, &sj)
+	if err != nil {
+		return err
+	}
+	s.totalIterations = sj.N
+	s.oldM, s.newM = sj.Mean, sj.Mean
+	s.oldS, s.newS = sj.M2, sj.M2
+	return nil
+}
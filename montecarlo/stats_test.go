@@ -1,6 +1,7 @@
 package montecarlo
 
 import (
+	"encoding/json"
 	"math"
 	"testing"
 
@@ -35,3 +36,43 @@ func TestRunningStat(t *testing.T) {
 
 	}
 }
+
+func TestMergeStats(t *testing.T) {
+	is := is.New(t)
+	scores := []int{14, 35, 71, 124, 10, 24, 55, 33, 87, 19}
+	for split := 0; split <= len(scores); split++ {
+		s1 := &Statistic{}
+		s2 := &Statistic{}
+		for idx, score := range scores {
+			if idx < split {
+				s1.Push(float64(score))
+			} else {
+				s2.Push(float64(score))
+			}
+		}
+		s1.Merge(s2)
+		is.Equal(s1.Iterations(), len(scores))
+		is.True(fuzzyEqual(s1.Mean(), 47.2))
+		is.True(fuzzyEqual(s1.Stdev(), 36.937785531891))
+	}
+}
+
+func TestStatsJSON(t *testing.T) {
+	is := is.New(t)
+	s := &Statistic{}
+	for _, score := range []int{10, 12, 23, 23, 16, 23, 21, 16} {
+		s.Push(float64(score))
+	}
+	bts, err := json.Marshal(s)
+	is.NoErr(err)
+	s2 := &Statistic{}
+	is.NoErr(json.Unmarshal(bts, s2))
+	is.Equal(s2.Iterations(), 8)
+	is.True(fuzzyEqual(s2.Mean(), 18))
+	is.True(fuzzyEqual(s2.Stdev(), 5.2372293656638))
+	// We should be able to keep pushing values after deserializing.
+	s.Push(30)
+	s2.Push(30)
+	is.True(fuzzyEqual(s2.Mean(), s.Mean()))
+	is.True(fuzzyEqual(s2.Stdev(), s.Stdev()))
+}
@@ -0,0 +1,116 @@
+package montecarlo
+
+import (
+	"math"
+
+	"github.com/rs/zerolog/log"
+)
+
+// StoppingCondition is the confidence level at which plays are pruned,
+// and at which a simulation stops automatically.
+type StoppingCondition int
+
+const (
+	// StopNone never prunes plays or stops the sim on its own.
+	StopNone StoppingCondition = iota
+	Stop95
+	Stop98
+	Stop99
+)
+
+const (
+	// MinPruningIterations is the number of iterations a play needs before
+	// we trust its statistics enough to prune anything.
+	MinPruningIterations = 100
+	// StoppingCheckInterval is how often (in iterations) we check whether
+	// plays can be pruned.
+	StoppingCheckInterval = 16
+)
+
+// zValues are the two-tailed z-scores for each stopping condition.
+var zValues = map[StoppingCondition]float64{
+	Stop95: 1.96,
+	Stop98: 2.326,
+	Stop99: 2.576,
+}
+
+// StoppingConditionFromString parses a confidence level such as "99" into
+// a StoppingCondition.
+func StoppingConditionFromString(s string) (StoppingCondition, bool) {
+	switch s {
+	case "none", "0":
+		return StopNone, true
+	case "95":
+		return Stop95, true
+	case "98":
+		return Stop98, true
+	case "99":
+		return Stop99, true
+	}
+	return StopNone, false
+}
+
+// pruneAndCheckForStop marks every play that is statistically worse than
+// the current leader as ignored, so that it will not be simmed any more.
+// It returns true if the leader is significantly ahead of every other play,
+// meaning that the simulation can stop.
+func (s *Simmer) pruneAndCheckForStop() bool {
+	if s.stoppingCondition == StopNone {
+		return false
+	}
+	zval := zValues[s.stoppingCondition]
+
+	type snapshot struct {
+		sp    *SimmedPlay
+		stats Statistic
+	}
+	snaps := []snapshot{}
+	for _, sp := range s.plays {
+		sp.Lock()
+		if !sp.ignore {
+			snaps = append(snaps, snapshot{sp, s.rankingStats(sp)})
+		}
+		sp.Unlock()
+	}
+	if len(snaps) <= 1 {
+		return true
+	}
+	leader := 0
+	for idx, snap := range snaps {
+		if snap.stats.Iterations() < MinPruningIterations {
+			return false
+		}
+		if snap.stats.Mean() > snaps[leader].stats.Mean() {
+			leader = idx
+		}
+	}
+	lstats := snaps[leader].stats
+	remaining := 0
+	for idx, snap := range snaps {
+		if idx == leader {
+			continue
+		}
+		diff := lstats.Mean() - snap.stats.Mean()
+		stderr := math.Sqrt(lstats.Variance()/float64(lstats.Iterations()) +
+			snap.stats.Variance()/float64(snap.stats.Iterations()))
+		if diff > zval*stderr {
+			log.Debug().Str("play", snap.sp.play.ShortDescription()).
+				Float64("diff", diff).Float64("stderr", stderr).Msg("pruning-play")
+			snap.sp.Lock()
+			snap.sp.ignore = true
+			snap.sp.Unlock()
+			continue
+		}
+		remaining++
+	}
+	return remaining == 0
+}
+
+// rankingStats returns the statistic that the play is ranked by. The caller
+// should hold the play's lock.
+func (s *Simmer) rankingStats(sp *SimmedPlay) Statistic {
+	if s.sortMetric == SortByWinPCT && s.winPCTs != nil {
+		return sp.winPCTStats
+	}
+	return sp.equityStats
+}
@@ -149,6 +149,28 @@ func NewScoringMove(score int, tiles alphabet.MachineWord,
 	return move
 }
 
+// Set makes the move into the scoring move NewScoringMove would create,
+// without allocating a new one. The tiles and leave are not copied.
+func (m *Move) Set(score int, tiles alphabet.MachineWord,
+	leave alphabet.MachineWord, vertical bool, tilesPlayed int,
+	alph *alphabet.Alphabet, rowStart int, colStart int, coords string) {
+
+	*m = Move{
+		action: MoveTypePlay, score: score, tiles: tiles, leave: leave, vertical: vertical,
+		bingo: tilesPlayed == 7, tilesPlayed: tilesPlayed, alph: alph,
+		rowStart: rowStart, colStart: colStart, coords: coords,
+	}
+}
+
+// CopyFrom makes the move a copy of the other move. The tiles and leave
+// are copied into the move's own, so that the other move can be reused.
+func (m *Move) CopyFrom(other *Move) {
+	tiles, leave := m.tiles[:0], m.leave[:0]
+	*m = *other
+	m.tiles = append(tiles, other.tiles...)
+	m.leave = append(leave, other.leave...)
+}
+
 // NewScoringMoveSimple takes in user-visible strings. Consider moving to this
 // (it is a little slower, though, so maybe only for tests)
 func NewScoringMoveSimple(score int, coords string, word string, leave string,
@@ -0,0 +1,169 @@
+package movegen
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/domino14/macondo/alphabet"
+)
+
+// A Coord is the row and column of a square, counting from 0.
+type Coord struct {
+	Row int
+	Col int
+}
+
+// Constraints restrict the plays a generator makes to the ones that meet
+// all of them; a zero field doesn't restrict anything. With constraints,
+// the generator only makes tile plays, no pass and no exchanges.
+type Constraints struct {
+	// Squares the play has to cover, with a tile of its own or one that is
+	// on the board already.
+	Squares []Coord
+	// Rows the play has to be on, if it is horizontal, and Columns the
+	// play has to be on, if it is vertical. If either is given, a play
+	// has to be on one of them.
+	Rows    []int
+	Columns []int
+	// RequiredTiles are tiles from the rack that the play has to use, and
+	// ForbiddenTiles tiles it can't use; the blank is BlankMachineLetter.
+	RequiredTiles  alphabet.MachineWord
+	ForbiddenTiles alphabet.MachineWord
+	MinTilesPlayed int
+	// WordPattern has to match the main word of the play, in upper case.
+	// It matches anywhere in the word unless it is anchored with ^ and $.
+	WordPattern *regexp.Regexp
+}
+
+// ConstrainedMoveGenerator is a move generator that can be restricted to
+// the plays that meet some constraints.
+type ConstrainedMoveGenerator interface {
+	MoveGenerator
+	// SetConstraints sets the constraints for the following generations,
+	// until they are set to nil.
+	SetConstraints(c *Constraints)
+}
+
+// SetConstraints restricts the plays generated from now on to the ones
+// that meet the constraints. Rows or squares the plays can't be on, and
+// forbidden tiles, are pruned while generating; the rest is checked when a
+// play is found, before it is scored. Nil removes the constraints.
+func (gen *GordonGenerator) SetConstraints(c *Constraints) {
+	gen.constraints = c
+	gen.forbiddenTiles = 0
+	gen.blankForbidden = false
+	gen.requiredTiles = gen.requiredTiles[:0]
+	if c == nil {
+		return
+	}
+	for _, t := range c.ForbiddenTiles {
+		if t == alphabet.BlankMachineLetter {
+			gen.blankForbidden = true
+		} else {
+			gen.forbiddenTiles.Set(t)
+		}
+	}
+	var counts [alphabet.MaxAlphabetSize + 1]int
+	for _, t := range c.RequiredTiles {
+		if idx, ok := t.IntrinsicTileIdx(); ok {
+			counts[idx]++
+		}
+	}
+	for t, n := range counts {
+		if n > 0 {
+			gen.requiredTiles = append(gen.requiredTiles, requiredTile{alphabet.MachineLetter(t), n})
+		}
+	}
+}
+
+// requiredTile is a tile a play has to use, and how many of it.
+type requiredTile struct {
+	tile  alphabet.MachineLetter
+	count int
+}
+
+// constrainRow works out whether the constraints allow plays on the row,
+// in the current direction, and if so sets the columns of the squares
+// every play on it has to cover.
+func (gen *GordonGenerator) constrainRow(row int) bool {
+	gen.minRequiredCol, gen.maxRequiredCol = -1, -1
+	c := gen.constraints
+	if c == nil {
+		return true
+	}
+	if len(c.Rows) > 0 || len(c.Columns) > 0 {
+		lines := c.Rows
+		if gen.vertical {
+			lines = c.Columns
+		}
+		onLine := false
+		for _, l := range lines {
+			onLine = onLine || l == row
+		}
+		if !onLine {
+			return false
+		}
+	}
+	for _, sq := range c.Squares {
+		r, col := sq.Row, sq.Col
+		if gen.vertical {
+			r, col = col, r
+		}
+		if r != row {
+			return false
+		}
+		if gen.minRequiredCol == -1 || col < gen.minRequiredCol {
+			gen.minRequiredCol = col
+		}
+		if col > gen.maxRequiredCol {
+			gen.maxRequiredCol = col
+		}
+	}
+	return true
+}
+
+// anchorCanCoverRequired returns whether plays from the current anchor can
+// cover the squares of the row they have to. They can't reach back to the
+// last anchor, so not the squares left of it.
+func (gen *GordonGenerator) anchorCanCoverRequired() bool {
+	return gen.minRequiredCol == -1 || gen.lastAnchorCol == 100 ||
+		gen.minRequiredCol > gen.lastAnchorCol
+}
+
+// meetsConstraints returns whether the play found, which starts on the
+// column of the current row, meets the constraints.
+func (gen *GordonGenerator) meetsConstraints(word alphabet.MachineWord, col, tilesPlayed int) bool {
+	c := gen.constraints
+	if tilesPlayed < c.MinTilesPlayed {
+		return false
+	}
+	if gen.minRequiredCol != -1 &&
+		(col > gen.minRequiredCol || col+len(word)-1 < gen.maxRequiredCol) {
+		return false
+	}
+	for _, rt := range gen.requiredTiles {
+		n := 0
+		for _, ml := range word {
+			if idx, ok := ml.IntrinsicTileIdx(); ok && idx == rt.tile {
+				n++
+			}
+		}
+		if n < rt.count {
+			return false
+		}
+	}
+	if c.WordPattern != nil {
+		alph := gen.alph
+		var sb strings.Builder
+		for i, ml := range word {
+			if ml == alphabet.PlayedThroughMarker {
+				ml = gen.board.GetLetter(gen.curRowIdx, col+i)
+			}
+			sb.WriteRune(ml.Unblank().UserVisible(alph))
+		}
+		if !c.WordPattern.MatchString(sb.String()) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package movegen
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/move"
+)
+
+// recordAll returns the descriptions of every play the generator finds
+// that is kept, sorted, dupes included.
+func recordAll(gen *GordonGenerator, rack *alphabet.Rack, keep func(*move.Move) bool) []string {
+	var plays []*move.Move
+	gen.GenAllWithRecorder(rack, false, func(m *move.Move) {
+		if m.Action() == move.MoveTypePlay {
+			p := &move.Move{}
+			p.CopyFrom(m)
+			plays = append(plays, p)
+		}
+	})
+	// The board is transposed while vertical plays are generated, so they
+	// are only looked at afterwards.
+	var descs []string
+	for _, p := range plays {
+		if keep(p) {
+			descs = append(descs, p.ShortDescription())
+		}
+	}
+	sort.Strings(descs)
+	return descs
+}
+
+// covers returns whether the play covers the square.
+func covers(m *move.Move, sq Coord) bool {
+	row, col, vertical := m.CoordsAndVertical()
+	if vertical {
+		return col == sq.Col && row <= sq.Row && sq.Row < row+len(m.Tiles())
+	}
+	return row == sq.Row && col <= sq.Col && sq.Col < col+len(m.Tiles())
+}
+
+// uses returns how many of the tile the play uses.
+func uses(m *move.Move, tile alphabet.MachineLetter) int {
+	n := 0
+	for _, ml := range m.Tiles() {
+		if idx, ok := ml.IntrinsicTileIdx(); ok && idx == tile {
+			n++
+		}
+	}
+	return n
+}
+
+func mainWord(bd *board.GameBoard, m *move.Move) string {
+	row, col, vertical := m.CoordsAndVertical()
+	var sb strings.Builder
+	for i, ml := range m.Tiles() {
+		if ml == alphabet.PlayedThroughMarker {
+			if vertical {
+				ml = bd.GetLetter(row+i, col)
+			} else {
+				ml = bd.GetLetter(row, col+i)
+			}
+		}
+		sb.WriteRune(ml.Unblank().UserVisible(m.Alphabet()))
+	}
+	return sb.String()
+}
+
+func TestConstraints(t *testing.T) {
+	is := is.New(t)
+	generator := setUpVsMatt(is)
+	alph := generator.gaddag.GetAlphabet()
+	bd := generator.board
+	rack := alphabet.RackFromString("AEI?RST", alph)
+	s, err := alph.Val('S')
+	is.NoErr(err)
+
+	i4 := Coord{3, 8}
+	k11 := Coord{10, 10}
+	k12 := Coord{11, 10}
+	pattern := regexp.MustCompile("^[A-Z]*ER[A-Z]?$")
+	tests := []struct {
+		name string
+		c    *Constraints
+		keep func(m *move.Move) bool
+	}{
+		{"square", &Constraints{Squares: []Coord{k11}},
+			func(m *move.Move) bool { return covers(m, k11) }},
+		{"square with a tile", &Constraints{Squares: []Coord{i4}},
+			func(m *move.Move) bool { return covers(m, i4) }},
+		{"two squares", &Constraints{Squares: []Coord{k11, k12}},
+			func(m *move.Move) bool { return covers(m, k11) && covers(m, k12) }},
+		{"row", &Constraints{Rows: []int{1, 12}},
+			func(m *move.Move) bool {
+				row, _, vertical := m.CoordsAndVertical()
+				return !vertical && (row == 1 || row == 12)
+			}},
+		{"column", &Constraints{Columns: []int{4}},
+			func(m *move.Move) bool {
+				_, col, vertical := m.CoordsAndVertical()
+				return vertical && col == 4
+			}},
+		{"using", &Constraints{RequiredTiles: alphabet.MachineWord{s, alphabet.BlankMachineLetter}},
+			func(m *move.Move) bool {
+				return uses(m, s) == 1 && uses(m, alphabet.BlankMachineLetter) == 1
+			}},
+		{"without", &Constraints{ForbiddenTiles: alphabet.MachineWord{s, alphabet.BlankMachineLetter}},
+			func(m *move.Move) bool {
+				return uses(m, s) == 0 && uses(m, alphabet.BlankMachineLetter) == 0
+			}},
+		{"min tiles", &Constraints{MinTilesPlayed: 6},
+			func(m *move.Move) bool { return m.TilesPlayed() >= 6 }},
+		{"word", &Constraints{WordPattern: pattern},
+			func(m *move.Move) bool { return pattern.MatchString(mainWord(bd, m)) }},
+		{"all", &Constraints{Squares: []Coord{k11}, ForbiddenTiles: alphabet.MachineWord{s},
+			MinTilesPlayed: 3, WordPattern: pattern},
+			func(m *move.Move) bool {
+				return covers(m, k11) && uses(m, s) == 0 && m.TilesPlayed() >= 3 &&
+					pattern.MatchString(mainWord(bd, m))
+			}},
+	}
+	for _, tc := range tests {
+		want := recordAll(generator, rack, tc.keep)
+		generator.SetConstraints(tc.c)
+		got := recordAll(generator, rack, func(*move.Move) bool { return true })
+		generator.SetConstraints(nil)
+		is.True(len(want) > 0) // tc.name
+		is.Equal(got, want)    // tc.name
+	}
+
+	// No play can cover opposite corners, and there is no pass either.
+	generator.SetConstraints(&Constraints{Squares: []Coord{{0, 0}, {14, 14}}})
+	generator.GenAll(rack, true)
+	is.Equal(len(generator.Plays()), 0)
+	generator.SetConstraints(nil)
+	generator.GenAll(rack, true)
+	is.True(len(generator.Plays()) > 0)
+}
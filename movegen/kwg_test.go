@@ -0,0 +1,60 @@
+package movegen
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cross_set"
+	"github.com/domino14/macondo/gaddag"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+func TestGenWithKWG(t *testing.T) {
+	is := is.New(t)
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	is.NoErr(err)
+	wordList := filepath.Join(DefaultConfig.LexiconPath, "America.txt")
+	dir, err := ioutil.TempDir("", "kwg")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	kwgFile := filepath.Join(dir, "America.kwg")
+	gaddagmaker.SaveKWG(kwgFile, gaddagmaker.GenerateKWG(wordList, true, false, ld.TileLetters()))
+
+	kwgGaddag, err := gaddag.LoadKWG(kwgFile, ld)
+	is.NoErr(err)
+	gd := gaddag.GaddagToSimpleGaddag(gaddagmaker.GenerateGaddag(wordList, true, false))
+	alph := gd.GetAlphabet()
+
+	boards := []board.VsWho{board.VsEd, board.VsMatt, board.VsOxy}
+	racks := []string{"AABDELT", "QZJXKVW", "AEINRST", "?EGRTU", "??EIRST"}
+
+	for _, b := range boards {
+		bd := board.MakeBoard(board.CrosswordGameBoard)
+		bd.SetToGame(alph, b)
+		cross_set.GenAllCrossSets(bd, gd, ld)
+		generator := NewGordonGenerator(gd, bd, ld)
+
+		kwgBoard := board.MakeBoard(board.CrosswordGameBoard)
+		kwgBoard.SetToGame(alph, b)
+		cross_set.GenAllCrossSets(kwgBoard, kwgGaddag, ld)
+		is.True(reflect.DeepEqual(kwgBoard, bd))
+		kwgGenerator := NewGordonGenerator(kwgGaddag, kwgBoard, ld)
+
+		for _, r := range racks {
+			generator.GenAll(alphabet.RackFromString(r, alph), false)
+			kwgGenerator.GenAll(alphabet.RackFromString(r, alph), false)
+			is.Equal(len(kwgGenerator.Plays()), len(generator.Plays()))
+			for i, m := range generator.Plays() {
+				is.Equal(kwgGenerator.Plays()[i].ShortDescription(), m.ShortDescription())
+				is.Equal(kwgGenerator.Plays()[i].Score(), m.Score())
+			}
+		}
+	}
+}
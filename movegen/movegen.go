@@ -33,6 +33,13 @@ type MoveGenerator interface {
 	Plays() []*move.Move
 }
 
+// StreamingMoveGenerator is a move generator that can also give its plays
+// to a recorder as it finds them, instead of keeping all of them.
+type StreamingMoveGenerator interface {
+	MoveGenerator
+	GenAllWithRecorder(rack *alphabet.Rack, addExchange bool, recorder PlayRecorder)
+}
+
 // GordonGenerator is the main move generation struct. It implements
 // Steven A. Gordon's algorithm from his paper "A faster Scrabble Move Generation
 // Algorithm"
@@ -52,10 +59,48 @@ type GordonGenerator struct {
 	numPossibleLetters int
 	sortingParameter   SortBy
 
+	// If recorder is set, plays are given to it instead of being kept.
+	// The scratch move and its tiles and leave are reused for every play.
+	recorder     PlayRecorder
+	numRecorded  int
+	scratch      move.Move
+	scratchTiles alphabet.MachineWord
+	scratchLeave alphabet.MachineWord
+	// coords caches the coordinates of every square, for both directions.
+	coords [2][]string
+
+	// Scratch space for shadow pruning; see shadow.go.
+	shadowAnchors []shadowAnchor
+	shadowRow     []shadowSquare
+	shadowTiles   []int
+	shadowMults   []int
+	shadowRackSet board.CrossSet
+
+	// constraints restrict the plays; see constraints.go. The tiles and
+	// columns are worked out from them.
+	constraints    *Constraints
+	forbiddenTiles board.CrossSet
+	blankForbidden bool
+	requiredTiles  []requiredTile
+	minRequiredCol int
+	maxRequiredCol int
+
+	// alphagrams is set instead of the gaddag for WordSmog, with scratch
+	// space for its generation; see wordsmog.go.
+	alphagrams  gaddag.GenericDawg
+	smogStart   int
+	smogWord    alphabet.MachineWord
+	smogEmpties []int
+	smogTiles   alphabet.MachineWord
+	smogUsed    []bool
+	smogLetters board.CrossSet
+	smogBoard   [alphabet.MaxAlphabetSize + 1]int
+
 	// These are pointers to the actual structures in `game`. They are
 	// duplicated here to speed up the algorithm, since we access them
 	// so frequently (yes it makes a difference)
 	gaddag *gaddag.SimpleGaddag
+	alph   *alphabet.Alphabet
 	board  *board.GameBoard
 	// Used for scoring:
 	letterDistribution *alphabet.LetterDistribution
@@ -67,6 +112,7 @@ func NewGordonGenerator(gd *gaddag.SimpleGaddag, board *board.GameBoard,
 
 	gen := &GordonGenerator{
 		gaddag:             gd,
+		alph:               gd.GetAlphabet(),
 		board:              board,
 		numPossibleLetters: int(gd.GetAlphabet().NumLetters()),
 		sortingParameter:   SortByScore,
@@ -86,6 +132,25 @@ func (gen *GordonGenerator) SetSortingParameter(s SortBy) {
 // been updated, as well as cross-sets / cross-scores.
 func (gen *GordonGenerator) GenAll(rack *alphabet.Rack, addExchange bool) {
 	gen.plays = []*move.Move{}
+	gen.genAll(rack, addExchange)
+	gen.dedupeAndSortPlays()
+}
+
+// GenAllWithRecorder generates all moves like GenAll, but instead of
+// keeping them, it gives every one to the recorder as soon as it is found.
+// Nothing is left in Plays afterwards. The plays are not deduplicated: a
+// single-tile play can be given twice, once in each direction.
+func (gen *GordonGenerator) GenAllWithRecorder(rack *alphabet.Rack, addExchange bool,
+	recorder PlayRecorder) {
+
+	gen.plays = nil
+	gen.recorder = recorder
+	gen.numRecorded = 0
+	defer func() { gen.recorder = nil }()
+	gen.genAll(rack, addExchange)
+}
+
+func (gen *GordonGenerator) genAll(rack *alphabet.Rack, addExchange bool) {
 	orientations := []board.BoardDirection{
 		board.HorizontalDirection, board.VerticalDirection}
 
@@ -97,22 +162,25 @@ func (gen *GordonGenerator) GenAll(rack *alphabet.Rack, addExchange bool) {
 	}
 
 	gen.addPassAndExchangeMoves(addExchange, rack)
-	gen.dedupeAndSortPlays()
 }
 
 func (gen *GordonGenerator) genByOrientation(rack *alphabet.Rack, dir board.BoardDirection) {
 	dim := gen.board.Dim()
 
 	for row := 0; row < dim; row++ {
+		if !gen.constrainRow(row) {
+			continue
+		}
 		gen.curRowIdx = row
 		// A bit of a hack. Set this to a large number at the beginning of
 		// every loop
 		gen.lastAnchorCol = 100
 		for col := 0; col < dim; col++ {
 			if gen.board.IsAnchor(row, col, dir) {
-				gen.curAnchorCol = col
-				gen.recursiveGen(col, alphabet.MachineWord([]alphabet.MachineLetter{}),
-					rack, gen.gaddag.GetRootNodeIndex())
+				if gen.anchorCanCoverRequired() {
+					gen.curAnchorCol = col
+					gen.genFromAnchor(rack)
+				}
 				gen.lastAnchorCol = col
 			}
 		}
@@ -134,6 +202,8 @@ func (gen *GordonGenerator) recursiveGen(col int, word alphabet.MachineWord, rac
 		csDirection = board.VerticalDirection
 	}
 	crossSet := gen.board.GetCrossSet(gen.curRowIdx, col, csDirection)
+	// The blank can still be a forbidden letter.
+	tileSet := crossSet &^ gen.forbiddenTiles
 
 	if !curSquare.IsEmpty() {
 		nnIdx := gen.gaddag.NextNodeIdx(nodeIdx, curLetter.Unblank())
@@ -144,7 +214,7 @@ func (gen *GordonGenerator) recursiveGen(col int, word alphabet.MachineWord, rac
 			if rack.LetArr[ml] == 0 {
 				continue
 			}
-			if crossSet.Allowed(ml) {
+			if tileSet.Allowed(ml) {
 				nnIdx := gen.gaddag.NextNodeIdx(nodeIdx, ml)
 				rack.Take(ml)
 				gen.tilesPlayed++
@@ -155,7 +225,7 @@ func (gen *GordonGenerator) recursiveGen(col int, word alphabet.MachineWord, rac
 
 		}
 
-		if rack.LetArr[alphabet.BlankMachineLetter] > 0 {
+		if rack.LetArr[alphabet.BlankMachineLetter] > 0 && !gen.blankForbidden {
 			// It's a blank. Loop only through letters in the cross-set.
 			for i := 0; i < gen.numPossibleLetters; i++ {
 				if crossSet.Allowed(alphabet.MachineLetter(i)) {
@@ -190,7 +260,7 @@ func (gen *GordonGenerator) goOn(curCol int, L alphabet.MachineLetter, word alph
 
 		// Check to see if there is a letter directly to the left.
 		if gen.gaddag.InLetterSet(L, oldNodeIdx) && noLetterDirectlyLeft && gen.tilesPlayed > 0 {
-			gen.recordPlay(word, gen.curRowIdx, curCol, rack.TilesOn(), gen.tilesPlayed)
+			gen.recordPlay(word, gen.curRowIdx, curCol, rack, gen.tilesPlayed)
 		}
 		if newNodeIdx == 0 {
 			return
@@ -207,8 +277,10 @@ func (gen *GordonGenerator) goOn(curCol int, L alphabet.MachineLetter, word alph
 		// Get the index of the SeparationToken
 		separationNodeIdx := gen.gaddag.NextNodeIdx(newNodeIdx, alphabet.SeparationMachineLetter)
 		// Check for no letter directly left AND room to the right (of the anchor
-		// square)
-		if separationNodeIdx != 0 && noLetterDirectlyLeft && gen.curAnchorCol < gen.board.Dim()-1 {
+		// square), and that the play won't miss a square it has to cover on
+		// the left.
+		if separationNodeIdx != 0 && noLetterDirectlyLeft && gen.curAnchorCol < gen.board.Dim()-1 &&
+			(gen.minRequiredCol == -1 || curCol <= gen.minRequiredCol) {
 			gen.recursiveGen(gen.curAnchorCol+1, word, rack, separationNodeIdx)
 		}
 
@@ -222,7 +294,7 @@ func (gen *GordonGenerator) goOn(curCol int, L alphabet.MachineLetter, word alph
 		noLetterDirectlyRight := curCol == gen.board.Dim()-1 ||
 			gen.board.GetSquare(gen.curRowIdx, curCol+1).IsEmpty()
 		if gen.gaddag.InLetterSet(L, oldNodeIdx) && noLetterDirectlyRight && gen.tilesPlayed > 0 {
-			gen.recordPlay(word, gen.curRowIdx, curCol-len(word)+1, rack.TilesOn(), gen.tilesPlayed)
+			gen.recordPlay(word, gen.curRowIdx, curCol-len(word)+1, rack, gen.tilesPlayed)
 		}
 		if newNodeIdx != 0 && curCol < gen.board.Dim()-1 {
 			// There is room to the right
@@ -232,7 +304,10 @@ func (gen *GordonGenerator) goOn(curCol int, L alphabet.MachineLetter, word alph
 }
 
 func (gen *GordonGenerator) recordPlay(word alphabet.MachineWord, startRow, startCol int,
-	leave alphabet.MachineWord, tilesPlayed int) {
+	rack *alphabet.Rack, tilesPlayed int) {
+	if gen.constraints != nil && !gen.meetsConstraints(word, startCol, tilesPlayed) {
+		return
+	}
 	row := startRow
 	col := startCol
 	if gen.vertical {
@@ -240,12 +315,21 @@ func (gen *GordonGenerator) recordPlay(word alphabet.MachineWord, startRow, star
 		// the board, so the row and col are actually transposed.
 		row, col = col, row
 	}
-	coords := move.ToBoardGameCoords(row, col, gen.vertical)
+	coords := gen.coordsFor(row, col)
+	alph := gen.alph
+	score := gen.scoreMove(word, startRow, startCol, tilesPlayed)
+	if gen.recorder != nil {
+		gen.scratchTiles = append(gen.scratchTiles[:0], word...)
+		gen.scratchLeave = rack.AppendTilesOn(gen.scratchLeave[:0])
+		gen.scratch.Set(score, gen.scratchTiles, gen.scratchLeave, gen.vertical,
+			tilesPlayed, alph, row, col, coords)
+		gen.numRecorded++
+		gen.recorder(&gen.scratch)
+		return
+	}
 	wordCopy := make([]alphabet.MachineLetter, len(word))
 	copy(wordCopy, word)
-	alph := gen.gaddag.GetAlphabet()
-	play := move.NewScoringMove(gen.scoreMove(word, startRow, startCol, tilesPlayed),
-		wordCopy, leave, gen.vertical,
+	play := move.NewScoringMove(score, wordCopy, rack.TilesOn(), gen.vertical,
 		tilesPlayed, alph, row, col, coords)
 
 	// if gen.sortingParameter == SortByEquity {
@@ -254,6 +338,25 @@ func (gen *GordonGenerator) recordPlay(word alphabet.MachineWord, startRow, star
 	gen.plays = append(gen.plays, play)
 }
 
+// coordsFor returns the coordinates of a play starting on the square, in
+// the current direction.
+func (gen *GordonGenerator) coordsFor(row, col int) string {
+	dim := gen.board.Dim()
+	dir := 0
+	if gen.vertical {
+		dir = 1
+	}
+	if len(gen.coords[dir]) != dim*dim {
+		gen.coords[dir] = make([]string, dim*dim)
+		for r := 0; r < dim; r++ {
+			for c := 0; c < dim; c++ {
+				gen.coords[dir][r*dim+c] = move.ToBoardGameCoords(r, c, gen.vertical)
+			}
+		}
+	}
+	return gen.coords[dir][row*dim+col]
+}
+
 func (gen *GordonGenerator) dedupeAndSortPlays() {
 	dupeMap := map[int]*move.Move{}
 
@@ -313,16 +416,19 @@ func (gen *GordonGenerator) addPassAndExchangeMoves(addExchange bool, rack *alph
 	// Only add a pass move if nothing else is possible. Note: in endgames,
 	// we will have to add a pass move another way (if it's a strategic pass).
 	// Probably in the endgame package.
-	if len(gen.plays) == 0 {
+	if gen.constraints != nil {
+		return
+	}
+	if len(gen.plays) == 0 && gen.numRecorded == 0 {
 		passMove := move.NewPassMove(tilesOnRack, rack.Alphabet())
 		// passMove.SetEquity(gen.strategy.Equity(passMove, gen.board, gen.bag, gen.oppRack))
-		gen.plays = append(gen.plays, passMove)
+		gen.addPlay(passMove)
 	}
 	if !addExchange {
 		return
 	}
 
-	alph := gen.gaddag.GetAlphabet()
+	alph := gen.alph
 	// Generate all exchange moves.
 	exchMap := make(map[string]*move.Move)
 	// Create a list of all machine letters
@@ -348,6 +454,17 @@ func (gen *GordonGenerator) addPassAndExchangeMoves(addExchange bool, rack *alph
 	}
 	for _, mv := range exchMap {
 		// mv.SetEquity(gen.strategy.Equity(mv, gen.board, gen.bag, gen.oppRack))
-		gen.plays = append(gen.plays, mv)
+		gen.addPlay(mv)
+	}
+}
+
+// addPlay keeps a play that isn't generated on the board, or gives it to
+// the recorder.
+func (gen *GordonGenerator) addPlay(m *move.Move) {
+	if gen.recorder != nil {
+		gen.numRecorded++
+		gen.recorder(m)
+		return
 	}
+	gen.plays = append(gen.plays, m)
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/domino14/macondo/gaddag"
 	"github.com/domino14/macondo/gaddagmaker"
 	"github.com/domino14/macondo/move"
+	"github.com/matryer/is"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -28,6 +29,18 @@ func TestMain(m *testing.M) {
 				panic(err)
 			}
 		}
+		dawgPath := filepath.Join(DefaultConfig.LexiconPath, "alphagrams", lex+".dawg")
+		if _, err := os.Stat(dawgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateAlphagramDawg(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.MkdirAll(filepath.Dir(dawgPath), 0755)
+			if err != nil {
+				panic(err)
+			}
+			err = os.Rename("out.dawg", dawgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
 	}
 	os.Exit(m.Run())
 }
@@ -660,3 +673,52 @@ func BenchmarkGenBothBlanks(b *testing.B) {
 		generator.GenAll(alphabet.RackFromString("DDESW??", alph), false)
 	}
 }
+
+func TestGenSuperCrosswordGame(t *testing.T) {
+	is := is.New(t)
+	gd, err := GaddagFromLexicon("America")
+	is.NoErr(err)
+	ld, err := alphabet.NamedLetterDistribution(&DefaultConfig, "englishsuper")
+	is.NoErr(err)
+	alph := gd.GetAlphabet()
+
+	bd := board.MakeBoard(board.SuperCrosswordGameBoard)
+	for _, p := range [][2]string{{"11K", "GFM"}, {"10L", "ZW"}, {"12J", "RUF"},
+		{"13I", "PHI"}, {"9M", "MU"}, {"14H", "BRO"}, {"15G", "UNC"},
+		{"8N", "XIE"}, {"7N", "EG"}, {"9P", "KORNEL"}, {"8T", "EJ"}} {
+		bd.PlayMove(move.NewScoringMoveSimple(0, p[0], p[1], "", alph), ld)
+	}
+	cross_set.GenAllCrossSets(bd, gd, ld)
+	generator := NewGordonGenerator(gd, bd, ld)
+	lex := gaddag.Lexicon{GenericDawg: gd}
+
+	for _, r := range []string{"AEINRST", "??EGRTU", "QZJXKVW", "AABDELT"} {
+		rack := alphabet.RackFromString(r, alph)
+		all := NewTopPlaysRecorder(1, scoreEquity)
+		generator.GenAllWithRecorder(rack, false, all.Record)
+		shadow := NewTopPlaysRecorder(1, scoreEquity)
+		generator.GenAllWithShadow(rack, false, shadow, scoreBound)
+		is.Equal(shadow.Plays()[0].Equity(), all.Plays()[0].Equity())
+
+		generator.GenAll(rack, false)
+		offStandardBoard := false
+		for _, m := range generator.Plays() {
+			if m.Action() != move.MoveTypePlay {
+				continue
+			}
+			row, col, vertical := m.CoordsAndVertical()
+			end := col + len(m.Tiles()) - 1
+			if vertical {
+				end = row + len(m.Tiles()) - 1
+			}
+			offStandardBoard = offStandardBoard || end > 14
+			// The cross-sets let through only plays that form words.
+			words, err := bd.FormedWords(m)
+			is.NoErr(err)
+			for _, w := range words {
+				is.True(lex.HasWord(w))
+			}
+		}
+		is.True(offStandardBoard)
+	}
+}
@@ -0,0 +1,105 @@
+package movegen
+
+import (
+	"math"
+
+	"github.com/domino14/macondo/move"
+)
+
+// PlayRecorder is called with every play a streaming generator finds. The
+// play is only valid during the call, as the generator reuses it for the
+// next play; a recorder that keeps it has to copy it.
+type PlayRecorder func(play *move.Move)
+
+// EquityFunc returns the equity of a play.
+type EquityFunc func(play *move.Move) float64
+
+// TopPlaysRecorder keeps the plays with the highest equity, and nothing
+// else, so that generating all the moves to pick a few of them doesn't
+// allocate every move.
+type TopPlaysRecorder struct {
+	n      int
+	equity EquityFunc
+	plays  []*move.Move
+	// spare is a play that fell out of the top plays, to copy the next
+	// play into.
+	spare *move.Move
+}
+
+// NewTopPlaysRecorder creates a recorder that keeps the n plays with the
+// highest equity, as given by the equity function.
+func NewTopPlaysRecorder(n int, equity EquityFunc) *TopPlaysRecorder {
+	if n < 1 {
+		n = 1
+	}
+	return &TopPlaysRecorder{
+		n:      n,
+		equity: equity,
+		plays:  make([]*move.Move, 0, n+1),
+	}
+}
+
+// NewBestPlayRecorder creates a recorder that only keeps the play with
+// the highest equity.
+func NewBestPlayRecorder(equity EquityFunc) *TopPlaysRecorder {
+	return NewTopPlaysRecorder(1, equity)
+}
+
+// Reset forgets the plays, for a new generation.
+func (r *TopPlaysRecorder) Reset() {
+	r.plays = make([]*move.Move, 0, r.n+1)
+	r.spare = nil
+}
+
+// Record assigns an equity to the play, and keeps a copy of it if it is
+// one of the top plays so far. Plays with the same equity stay in the
+// order they were found.
+func (r *TopPlaysRecorder) Record(play *move.Move) {
+	e := r.equity(play)
+	if len(r.plays) == r.n && e <= r.plays[r.n-1].Equity() {
+		return
+	}
+	if play.Action() == move.MoveTypePlay && play.TilesPlayed() == 1 {
+		// The same single-tile play is found in both directions.
+		key := play.UniqueSingleTileKey()
+		for _, p := range r.plays {
+			if p.Action() == move.MoveTypePlay && p.TilesPlayed() == 1 &&
+				p.UniqueSingleTileKey() == key {
+				return
+			}
+		}
+	}
+	m := r.spare
+	r.spare = nil
+	if m == nil {
+		m = &move.Move{}
+	}
+	m.CopyFrom(play)
+	m.SetEquity(e)
+
+	r.plays = append(r.plays, m)
+	i := len(r.plays) - 1
+	for ; i > 0 && r.plays[i-1].Equity() < e; i-- {
+		r.plays[i] = r.plays[i-1]
+	}
+	r.plays[i] = m
+	if len(r.plays) > r.n {
+		r.spare = r.plays[r.n]
+		r.plays = r.plays[:r.n]
+	}
+}
+
+// Threshold returns the equity a play has to beat to be kept: that of the
+// last of the top plays, or minus infinity until there are n of them.
+func (r *TopPlaysRecorder) Threshold() float64 {
+	if len(r.plays) < r.n {
+		return math.Inf(-1)
+	}
+	return r.plays[r.n-1].Equity()
+}
+
+// Plays returns the top plays, sorted by equity, best first. The moves
+// are not reused once the recorder is reset, so they can be kept.
+func (r *TopPlaysRecorder) Plays() []*move.Move {
+	return r.plays
+}
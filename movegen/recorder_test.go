@@ -0,0 +1,100 @@
+package movegen
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cross_set"
+	"github.com/domino14/macondo/move"
+)
+
+func scoreEquity(m *move.Move) float64 {
+	return float64(m.Score())
+}
+
+func setUpVsMatt(is *is.I) *GordonGenerator {
+	gd, err := GaddagFromLexicon("America")
+	is.NoErr(err)
+	bd := board.MakeBoard(board.CrosswordGameBoard)
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	is.NoErr(err)
+	bd.SetToGame(gd.GetAlphabet(), board.VsMatt)
+	cross_set.GenAllCrossSets(bd, gd, ld)
+	return NewGordonGenerator(gd, bd, ld)
+}
+
+func TestGenAllWithRecorder(t *testing.T) {
+	is := is.New(t)
+	generator := setUpVsMatt(is)
+	rack := alphabet.RackFromString("AABDELT", generator.gaddag.GetAlphabet())
+
+	generator.GenAll(rack, true)
+	all := generator.Plays()
+	dupes := 0
+	for _, m := range all {
+		if m.HasDupe() {
+			dupes++
+		}
+	}
+
+	// Every play is given to the recorder, dupes included.
+	recorded := 0
+	generator.GenAllWithRecorder(rack, true, func(m *move.Move) {
+		recorded++
+	})
+	is.Equal(recorded, len(all)+dupes)
+	is.Equal(len(generator.Plays()), 0)
+
+	// The top plays are the same as the top of the sorted list.
+	n := 10
+	recorder := NewTopPlaysRecorder(n, scoreEquity)
+	generator.GenAllWithRecorder(rack, true, recorder.Record)
+	top := recorder.Plays()
+	is.Equal(len(top), n)
+	descs := map[string]bool{}
+	for i, m := range top {
+		is.Equal(m.Score(), all[i].Score())
+		is.Equal(m.Equity(), float64(m.Score()))
+		is.True(!descs[m.ShortDescription()])
+		descs[m.ShortDescription()] = true
+	}
+
+	best := NewBestPlayRecorder(scoreEquity)
+	generator.GenAllWithRecorder(rack, true, best.Record)
+	is.Equal(len(best.Plays()), 1)
+	is.Equal(best.Plays()[0].Score(), all[0].Score())
+	// The kept play is a copy, which later generations don't touch.
+	kept := best.Plays()[0]
+	desc := kept.ShortDescription()
+	best.Reset()
+	generator.GenAllWithRecorder(alphabet.RackFromString("EEIOUUV", generator.gaddag.GetAlphabet()),
+		false, best.Record)
+	is.Equal(kept.ShortDescription(), desc)
+}
+
+func BenchmarkGenBestPlay(b *testing.B) {
+	is := is.New(b)
+	generator := setUpVsMatt(is)
+	rack := alphabet.RackFromString("AABDELT", generator.gaddag.GetAlphabet())
+	recorder := NewBestPlayRecorder(scoreEquity)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recorder.Reset()
+		generator.GenAllWithRecorder(rack, true, recorder.Record)
+	}
+}
+
+func BenchmarkGenAllForBestPlay(b *testing.B) {
+	is := is.New(b)
+	generator := setUpVsMatt(is)
+	rack := alphabet.RackFromString("AABDELT", generator.gaddag.GetAlphabet())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		generator.GenAll(rack, true)
+	}
+}
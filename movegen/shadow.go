@@ -0,0 +1,290 @@
+package movegen
+
+import (
+	"math"
+	"sort"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+)
+
+// EquityBound returns an upper bound on the equity of a play that scores
+// at most the given score, playing the given number of tiles.
+type EquityBound func(score, tilesPlayed int) float64
+
+// ShadowMoveGenerator is a streaming move generator that can skip the
+// anchors where no play could make it into the recorder's top plays.
+type ShadowMoveGenerator interface {
+	StreamingMoveGenerator
+	GenAllWithShadow(rack *alphabet.Rack, addExchange bool,
+		recorder *TopPlaysRecorder, bound EquityBound)
+}
+
+// shadowAnchor is an anchor to generate plays from, with an upper bound on
+// the equity of every play generated from it.
+type shadowAnchor struct {
+	row           int
+	col           int
+	lastAnchorCol int
+	vertical      bool
+	bound         float64
+}
+
+// shadowSquare is what the shadow needs to know about a square of the row.
+type shadowSquare struct {
+	occupied bool
+	// letterScore is the score of the tile on the square, if there is one.
+	letterScore int
+	// usable is whether a tile from the rack might go on the empty square.
+	usable     bool
+	letterMult int
+	wordMult   int
+	crossScore int
+	crossWord  bool
+}
+
+// GenAllWithShadow generates the plays that can make it into the
+// recorder's top plays, and gives them to it, like GenAllWithRecorder.
+// Before generating anything, it "shadow plays" every anchor: it works out
+// the most any play from the anchor could score, by putting the best tiles
+// of the rack on the best squares without looking at the lexicon, and
+// bounds its equity with the bound function. The anchors are then visited
+// best bound first, and generation stops as soon as no anchor left could
+// beat the plays the recorder has.
+func (gen *GordonGenerator) GenAllWithShadow(rack *alphabet.Rack, addExchange bool,
+	recorder *TopPlaysRecorder, bound EquityBound) {
+
+	gen.plays = nil
+	gen.recorder = recorder.Record
+	gen.numRecorded = 0
+	defer func() { gen.recorder = nil }()
+
+	gen.setShadowTiles(rack)
+	gen.shadowAnchors = gen.shadowAnchors[:0]
+	gen.vertical = false
+	gen.shadowByOrientation(rack, board.HorizontalDirection, bound)
+	gen.board.Transpose()
+	gen.vertical = true
+	gen.shadowByOrientation(rack, board.VerticalDirection, bound)
+	transposed := true
+
+	sort.SliceStable(gen.shadowAnchors, func(i, j int) bool {
+		return gen.shadowAnchors[i].bound > gen.shadowAnchors[j].bound
+	})
+	for _, a := range gen.shadowAnchors {
+		if a.bound <= recorder.Threshold() {
+			break
+		}
+		if a.vertical != transposed {
+			gen.board.Transpose()
+			transposed = a.vertical
+		}
+		gen.vertical = a.vertical
+		gen.constrainRow(a.row)
+		gen.curRowIdx = a.row
+		gen.curAnchorCol = a.col
+		gen.lastAnchorCol = a.lastAnchorCol
+		gen.genFromAnchor(rack)
+	}
+	if transposed {
+		gen.board.Transpose()
+	}
+	gen.addPassAndExchangeMoves(addExchange, rack)
+}
+
+// setShadowTiles sets the scores of the tiles on the rack, highest first,
+// and the cross-set of the letters on it.
+func (gen *GordonGenerator) setShadowTiles(rack *alphabet.Rack) {
+	gen.shadowTiles = gen.shadowTiles[:0]
+	gen.shadowRackSet = 0
+	for ml, n := range rack.LetArr {
+		if n == 0 {
+			continue
+		}
+		score := 0
+		if alphabet.MachineLetter(ml) == alphabet.BlankMachineLetter {
+			gen.shadowRackSet = board.TrivialCrossSet
+		} else {
+			score = gen.letterDistribution.Score(alphabet.MachineLetter(ml))
+			gen.shadowRackSet.Set(alphabet.MachineLetter(ml))
+		}
+		for i := 0; i < n; i++ {
+			gen.shadowTiles = append(gen.shadowTiles, score)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(gen.shadowTiles)))
+}
+
+func (gen *GordonGenerator) shadowByOrientation(rack *alphabet.Rack, dir board.BoardDirection,
+	bound EquityBound) {
+
+	dim := gen.board.Dim()
+	for row := 0; row < dim; row++ {
+		if !gen.constrainRow(row) {
+			continue
+		}
+		rowSet := false
+		lastAnchorCol := 100 // as in genByOrientation
+		for col := 0; col < dim; col++ {
+			if !gen.board.IsAnchor(row, col, dir) {
+				continue
+			}
+			gen.lastAnchorCol = lastAnchorCol
+			if !gen.anchorCanCoverRequired() {
+				lastAnchorCol = col
+				continue
+			}
+			if !rowSet {
+				gen.setShadowRow(row)
+				rowSet = true
+			}
+			gen.shadowAnchors = append(gen.shadowAnchors, shadowAnchor{
+				row:           row,
+				col:           col,
+				lastAnchorCol: lastAnchorCol,
+				vertical:      gen.vertical,
+				bound:         gen.shadowBound(col, lastAnchorCol, bound),
+			})
+			lastAnchorCol = col
+		}
+	}
+}
+
+func (gen *GordonGenerator) setShadowRow(row int) {
+	dim := gen.board.Dim()
+	csDirection := gen.crossDirection()
+	if cap(gen.shadowRow) < dim {
+		gen.shadowRow = make([]shadowSquare, dim)
+	}
+	gen.shadowRow = gen.shadowRow[:dim]
+	for col := 0; col < dim; col++ {
+		sq := gen.board.GetSquare(row, col)
+		s := shadowSquare{letterMult: 1, wordMult: 1}
+		if !sq.IsEmpty() {
+			s.occupied = true
+			if ml := sq.Letter(); ml < alphabet.BlankOffset {
+				s.letterScore = gen.letterDistribution.Score(ml)
+			}
+			gen.shadowRow[col] = s
+			continue
+		}
+		s.usable = gen.board.GetCrossSet(row, col, csDirection)&gen.shadowRackSet != 0
+		switch gen.board.GetBonus(row, col) {
+		case board.Bonus4WS:
+			s.wordMult = 4
+		case board.Bonus3WS:
+			s.wordMult = 3
+		case board.Bonus2WS:
+			s.wordMult = 2
+		case board.Bonus2LS:
+			s.letterMult = 2
+		case board.Bonus3LS:
+			s.letterMult = 3
+		case board.Bonus4LS:
+			s.letterMult = 4
+		}
+		s.crossScore = gen.board.GetCrossScore(row, col, csDirection)
+		s.crossWord = (row > 0 && gen.board.HasLetter(row-1, col)) ||
+			(row < dim-1 && gen.board.HasLetter(row+1, col))
+		gen.shadowRow[col] = s
+	}
+}
+
+// shadowBound returns the highest equity bound of any stretch of the row
+// that a play from the anchor could cover: it has to cover the anchor, not
+// reach the last anchor, have a tile or the edge of the board on neither
+// side, and have room for at most as many tiles as there are on the rack.
+func (gen *GordonGenerator) shadowBound(anchor, lastAnchorCol int, bound EquityBound) float64 {
+	row := gen.shadowRow
+	numTiles := len(gen.shadowTiles)
+	best := math.Inf(-1)
+
+	leftmost := 0
+	if lastAnchorCol != 100 {
+		leftmost = lastAnchorCol + 1
+	}
+	emptiesLeft := 0
+	for start := anchor; start >= leftmost; start-- {
+		if !row[start].occupied {
+			if !row[start].usable {
+				break
+			}
+			emptiesLeft++
+			if emptiesLeft > numTiles {
+				break
+			}
+		}
+		if start > 0 && row[start-1].occupied {
+			continue
+		}
+		empties := emptiesLeft
+		for end := anchor; end < len(row); end++ {
+			if end > anchor && !row[end].occupied {
+				if !row[end].usable {
+					break
+				}
+				empties++
+				if empties > numTiles {
+					break
+				}
+			}
+			if end < len(row)-1 && row[end+1].occupied {
+				continue
+			}
+			if empties == 0 {
+				continue
+			}
+			if b := bound(gen.shadowScore(start, end, empties), empties); b > best {
+				best = b
+			}
+		}
+	}
+	return best
+}
+
+// shadowScore returns the most a play covering the squares from start to
+// end could score, with the given number of tiles. Every tile's score
+// counts once for every time it is multiplied, in the main word and in the
+// cross word, so the best the rack can do is to put its highest scoring
+// tiles on the squares that multiply them the most.
+func (gen *GordonGenerator) shadowScore(start, end, tilesPlayed int) int {
+	row := gen.shadowRow[start : end+1]
+	wordMult := 1
+	mainScore := 0
+	crossScores := 0
+	for _, s := range row {
+		if s.occupied {
+			mainScore += s.letterScore
+		} else {
+			wordMult *= s.wordMult
+			if s.crossWord {
+				crossScores += s.crossScore * s.wordMult
+			}
+		}
+	}
+	gen.shadowMults = gen.shadowMults[:0]
+	for _, s := range row {
+		if s.occupied {
+			continue
+		}
+		m := s.letterMult * wordMult
+		if s.crossWord {
+			m += s.letterMult * s.wordMult
+		}
+		// Insert it, keeping the multipliers sorted highest first.
+		gen.shadowMults = append(gen.shadowMults, m)
+		i := len(gen.shadowMults) - 1
+		for ; i > 0 && gen.shadowMults[i-1] < m; i-- {
+			gen.shadowMults[i] = gen.shadowMults[i-1]
+		}
+		gen.shadowMults[i] = m
+	}
+	score := mainScore*wordMult + crossScores
+	for i, m := range gen.shadowMults {
+		score += m * gen.shadowTiles[i]
+	}
+	if tilesPlayed == 7 {
+		score += 50
+	}
+	return score
+}
@@ -0,0 +1,86 @@
+package movegen
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cross_set"
+	"github.com/domino14/macondo/move"
+)
+
+func scoreBound(score, tilesPlayed int) float64 {
+	return float64(score)
+}
+
+// leaveEquity gives every tile kept a bonus, so that the best play by
+// equity isn't always the best play by score.
+func leaveEquity(m *move.Move) float64 {
+	return float64(m.Score() + 6*len(m.Leave()))
+}
+
+func leaveBound(rackSize int) EquityBound {
+	return func(score, tilesPlayed int) float64 {
+		return float64(score + 6*(rackSize-tilesPlayed))
+	}
+}
+
+func TestGenAllWithShadow(t *testing.T) {
+	is := is.New(t)
+	gd, err := GaddagFromLexicon("America")
+	is.NoErr(err)
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	is.NoErr(err)
+	alph := gd.GetAlphabet()
+
+	boards := []board.VsWho{board.VsEd, board.VsMatt, board.VsJeremy,
+		board.VsOxy, board.VsCanik, board.VsJoel}
+	racks := []string{"AABDELT", "EEIOUUV", "?AEINST", "QZJXKVW", "AEINRST",
+		"??EGRTU", "CDEFHIL", "A"}
+
+	for _, b := range boards {
+		bd := board.MakeBoard(board.CrosswordGameBoard)
+		bd.SetToGame(alph, b)
+		cross_set.GenAllCrossSets(bd, gd, ld)
+		generator := NewGordonGenerator(gd, bd, ld)
+		for _, r := range racks {
+			rack := alphabet.RackFromString(r, alph)
+			for _, n := range []int{1, 3} {
+				all := NewTopPlaysRecorder(n, scoreEquity)
+				generator.GenAllWithRecorder(rack, false, all.Record)
+				shadow := NewTopPlaysRecorder(n, scoreEquity)
+				generator.GenAllWithShadow(rack, false, shadow, scoreBound)
+				is.Equal(len(shadow.Plays()), len(all.Plays()))
+				for i := range all.Plays() {
+					is.Equal(shadow.Plays()[i].Equity(), all.Plays()[i].Equity())
+				}
+
+				all = NewTopPlaysRecorder(n, leaveEquity)
+				generator.GenAllWithRecorder(rack, true, all.Record)
+				shadow = NewTopPlaysRecorder(n, leaveEquity)
+				generator.GenAllWithShadow(rack, true, shadow, leaveBound(len(r)))
+				is.Equal(len(shadow.Plays()), len(all.Plays()))
+				for i := range all.Plays() {
+					is.Equal(shadow.Plays()[i].Equity(), all.Plays()[i].Equity())
+				}
+			}
+			// The board is left as it was.
+			is.True(!bd.IsTransposed())
+		}
+	}
+}
+
+func BenchmarkGenBestPlayWithShadow(b *testing.B) {
+	is := is.New(b)
+	generator := setUpVsMatt(is)
+	rack := alphabet.RackFromString("AABDELT", generator.gaddag.GetAlphabet())
+	recorder := NewBestPlayRecorder(scoreEquity)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recorder.Reset()
+		generator.GenAllWithShadow(rack, true, recorder, scoreBound)
+	}
+}
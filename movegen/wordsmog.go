@@ -0,0 +1,265 @@
+package movegen
+
+import (
+	"errors"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cache"
+	"github.com/domino14/macondo/gaddag"
+	"github.com/domino14/macondo/game"
+)
+
+// In WordSmog, a word is good if any anagram of it is in the lexicon, so
+// the letters of a play don't have to go in any order, and a gaddag can't
+// be used. Instead, every stretch of the row that a play from the anchor
+// could cover is tried, like the shadow does, and the alphagrams that can
+// be made from its letters on the board and the tiles on the rack are
+// looked up in a dawg of alphagrams. The tiles of every alphagram found are
+// then put on the empty squares of the stretch in every order the cross-sets
+// allow.
+
+// NewWordSmogGenerator returns a move generator for WordSmog, given a dawg
+// of the alphagrams of the lexicon; see gaddagmaker.GenerateAlphagramDawg.
+// The board needs cross-sets made with the same lexicon, by a
+// cross_set.LexiconCrossSetGenerator.
+func NewWordSmogGenerator(alphagrams gaddag.GenericDawg, board *board.GameBoard,
+	ld *alphabet.LetterDistribution) *GordonGenerator {
+
+	gen := &GordonGenerator{
+		alphagrams:         alphagrams,
+		alph:               alphagrams.GetAlphabet(),
+		board:              board,
+		numPossibleLetters: int(alphagrams.GetAlphabet().NumLetters()),
+		sortingParameter:   SortByScore,
+		letterDistribution: ld,
+	}
+	return gen
+}
+
+// NewGeneratorForGame returns a move generator for the game's board,
+// lexicon and variant, loading the gaddag or the alphagrams it needs.
+func NewGeneratorForGame(g *game.Game) (*GordonGenerator, error) {
+	ld := g.Bag().LetterDistribution()
+	if g.Variant() == game.VariantWordSmog {
+		obj, err := cache.Load(g.Config(), "alphagrams:"+g.LexiconName(),
+			gaddag.AlphagramCacheLoadFunc)
+		if err != nil {
+			return nil, err
+		}
+		dawg, ok := obj.(*gaddag.SimpleDawg)
+		if !ok {
+			return nil, errors.New("type-assertion failed; dawg")
+		}
+		return NewWordSmogGenerator(dawg, g.Board(), ld), nil
+	}
+	obj, err := cache.Load(g.Config(), "gaddag:"+g.LexiconName(), gaddag.CacheLoadFunc)
+	if err != nil {
+		return nil, err
+	}
+	gd, ok := obj.(*gaddag.SimpleGaddag)
+	if !ok {
+		return nil, errors.New("type-assertion failed; gaddag")
+	}
+	return NewGordonGenerator(gd, g.Board(), ld), nil
+}
+
+// genFromAnchor generates the plays from the current anchor.
+func (gen *GordonGenerator) genFromAnchor(rack *alphabet.Rack) {
+	if gen.alphagrams != nil {
+		gen.genWordSmog(rack)
+		return
+	}
+	gen.recursiveGen(gen.curAnchorCol, alphabet.MachineWord([]alphabet.MachineLetter{}),
+		rack, gen.gaddag.GetRootNodeIndex())
+}
+
+// genWordSmog generates the WordSmog plays from the current anchor. The
+// stretches it tries are the ones shadowBound does.
+func (gen *GordonGenerator) genWordSmog(rack *alphabet.Rack) {
+	row := gen.curRowIdx
+	anchor := gen.curAnchorCol
+	dim := gen.board.Dim()
+	csDirection := gen.crossDirection()
+	numTiles := int(rack.NumTiles())
+
+	tileSet := board.CrossSet(0)
+	for ml := 0; ml < gen.numPossibleLetters; ml++ {
+		if rack.LetArr[ml] > 0 {
+			tileSet.Set(alphabet.MachineLetter(ml))
+		}
+	}
+	tileSet &^= gen.forbiddenTiles
+	if rack.LetArr[alphabet.BlankMachineLetter] > 0 && !gen.blankForbidden {
+		tileSet = board.TrivialCrossSet
+	}
+	usable := func(col int) bool {
+		return gen.board.GetCrossSet(row, col, csDirection)&tileSet != 0
+	}
+
+	leftmost := 0
+	if gen.lastAnchorCol != 100 {
+		leftmost = gen.lastAnchorCol + 1
+	}
+	emptiesLeft := 0
+	for start := anchor; start >= leftmost; start-- {
+		if gen.board.GetSquare(row, start).IsEmpty() {
+			if !usable(start) {
+				break
+			}
+			emptiesLeft++
+			if emptiesLeft > numTiles {
+				break
+			}
+		}
+		if start > 0 && gen.board.HasLetter(row, start-1) {
+			continue
+		}
+		if gen.minRequiredCol != -1 && start > gen.minRequiredCol {
+			continue
+		}
+		empties := emptiesLeft
+		for end := anchor; end < dim; end++ {
+			if end > anchor && gen.board.GetSquare(row, end).IsEmpty() {
+				if !usable(end) {
+					break
+				}
+				empties++
+				if empties > numTiles {
+					break
+				}
+			}
+			if end < dim-1 && gen.board.HasLetter(row, end+1) {
+				continue
+			}
+			if empties == 0 || end == start || end < gen.maxRequiredCol {
+				continue
+			}
+			gen.genSmogStretch(start, end, rack)
+		}
+	}
+}
+
+// genSmogStretch generates the plays that cover the squares from start to
+// end of the current row.
+func (gen *GordonGenerator) genSmogStretch(start, end int, rack *alphabet.Rack) {
+	row := gen.curRowIdx
+	csDirection := gen.crossDirection()
+	gen.smogStart = start
+	gen.smogWord = gen.smogWord[:0]
+	gen.smogEmpties = gen.smogEmpties[:0]
+	gen.smogTiles = gen.smogTiles[:0]
+	gen.smogLetters = 0
+	for i := range gen.smogBoard {
+		gen.smogBoard[i] = 0
+	}
+	for col := start; col <= end; col++ {
+		sq := gen.board.GetSquare(row, col)
+		if sq.IsEmpty() {
+			gen.smogWord = append(gen.smogWord, 0)
+			gen.smogEmpties = append(gen.smogEmpties, col)
+			gen.smogLetters |= gen.board.GetCrossSet(row, col, csDirection)
+		} else {
+			gen.smogWord = append(gen.smogWord, alphabet.PlayedThroughMarker)
+			gen.smogBoard[sq.Letter().Unblank()]++
+		}
+	}
+	gen.smogSearch(gen.alphagrams.GetRootNodeIndex(), 0, false, 0, rack)
+}
+
+// smogSearch goes down the alphagram dawg, one letter of the alphagram at
+// a time, in alphabetical order. Every letter is either the smallest
+// letter left on the board in the stretch, or a tile from the rack that
+// comes before it; so every alphagram and every choice of tiles is made
+// only once, with a letter's board tiles first, then its natural tiles,
+// then its blanks.
+func (gen *GordonGenerator) smogSearch(nodeIdx uint32, minLetter alphabet.MachineLetter,
+	lastWasBlank bool, pos int, rack *alphabet.Rack) {
+
+	boardLetter := alphabet.MachineLetter(gen.numPossibleLetters)
+	for ml := alphabet.MachineLetter(0); int(ml) < gen.numPossibleLetters; ml++ {
+		if gen.smogBoard[ml] > 0 {
+			boardLetter = ml
+			break
+		}
+	}
+	last := pos == len(gen.smogWord)-1
+	for ml := minLetter; ml <= boardLetter && int(ml) < gen.numPossibleLetters; ml++ {
+		var nextIdx uint32
+		if last {
+			if !gen.alphagrams.InLetterSet(ml, nodeIdx) {
+				continue
+			}
+		} else {
+			nextIdx = gen.alphagrams.NextNodeIdx(nodeIdx, ml)
+			if nextIdx == 0 {
+				continue
+			}
+		}
+		if ml == boardLetter {
+			gen.smogBoard[ml]--
+			gen.smogStep(nextIdx, ml, false, pos, rack)
+			gen.smogBoard[ml]++
+			continue
+		}
+		if len(gen.smogTiles) == len(gen.smogEmpties) || !gen.smogLetters.Allowed(ml) {
+			continue
+		}
+		if rack.LetArr[ml] > 0 && !gen.forbiddenTiles.Allowed(ml) &&
+			!(lastWasBlank && ml == minLetter) {
+			rack.Take(ml)
+			gen.smogTiles = append(gen.smogTiles, ml)
+			gen.smogStep(nextIdx, ml, false, pos, rack)
+			gen.smogTiles = gen.smogTiles[:len(gen.smogTiles)-1]
+			rack.Add(ml)
+		}
+		if rack.LetArr[alphabet.BlankMachineLetter] > 0 && !gen.blankForbidden {
+			rack.Take(alphabet.BlankMachineLetter)
+			gen.smogTiles = append(gen.smogTiles, ml.Blank())
+			gen.smogStep(nextIdx, ml, true, pos, rack)
+			gen.smogTiles = gen.smogTiles[:len(gen.smogTiles)-1]
+			rack.Add(alphabet.BlankMachineLetter)
+		}
+	}
+}
+
+func (gen *GordonGenerator) smogStep(nextIdx uint32, ml alphabet.MachineLetter,
+	blank bool, pos int, rack *alphabet.Rack) {
+
+	if pos == len(gen.smogWord)-1 {
+		gen.placeSmogTiles(0, rack)
+		return
+	}
+	gen.smogSearch(nextIdx, ml, blank, pos+1, rack)
+}
+
+// placeSmogTiles puts the tiles of the alphagram found on the empty
+// squares of the stretch, from the i-th one on, in every order the
+// cross-sets allow, and records the plays. Equal tiles are next to each
+// other, so an order is only tried once.
+func (gen *GordonGenerator) placeSmogTiles(i int, rack *alphabet.Rack) {
+	if i == len(gen.smogEmpties) {
+		gen.recordPlay(gen.smogWord, gen.curRowIdx, gen.smogStart, rack, len(gen.smogEmpties))
+		return
+	}
+	if i == 0 {
+		gen.smogUsed = gen.smogUsed[:0]
+		for range gen.smogTiles {
+			gen.smogUsed = append(gen.smogUsed, false)
+		}
+	}
+	col := gen.smogEmpties[i]
+	crossSet := gen.board.GetCrossSet(gen.curRowIdx, col, gen.crossDirection())
+	for j, t := range gen.smogTiles {
+		if gen.smogUsed[j] || (j > 0 && t == gen.smogTiles[j-1] && !gen.smogUsed[j-1]) {
+			continue
+		}
+		if !crossSet.Allowed(t.Unblank()) {
+			continue
+		}
+		gen.smogUsed[j] = true
+		gen.smogWord[col-gen.smogStart] = t
+		gen.placeSmogTiles(i+1, rack)
+		gen.smogUsed[j] = false
+	}
+}
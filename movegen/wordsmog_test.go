@@ -0,0 +1,76 @@
+package movegen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/board"
+	"github.com/domino14/macondo/cross_set"
+	"github.com/domino14/macondo/gaddag"
+	"github.com/domino14/macondo/move"
+)
+
+func TestGenWordSmog(t *testing.T) {
+	is := is.New(t)
+	gd, err := GaddagFromLexicon("America")
+	is.NoErr(err)
+	dawg, err := gaddag.LoadDawg(filepath.Join(DefaultConfig.LexiconPath, "alphagrams", "America.dawg"))
+	is.NoErr(err)
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	is.NoErr(err)
+	alph := gd.GetAlphabet()
+	lex := gaddag.AlphagramLexicon{GenericDawg: dawg}
+
+	boards := []board.VsWho{board.VsEd, board.VsMatt, board.VsOxy}
+	racks := []string{"AABDELT", "QZJXKVW", "AEINRST", "?EGRTU", "EEIOUUV"}
+
+	for _, b := range boards {
+		bd := board.MakeBoard(board.CrosswordGameBoard)
+		bd.SetToGame(alph, b)
+		cross_set.GenAllCrossSets(bd, gd, ld)
+		generator := NewGordonGenerator(gd, bd, ld)
+
+		smogBoard := board.MakeBoard(board.CrosswordGameBoard)
+		smogBoard.SetToGame(alph, b)
+		cross_set.LexiconCrossSetGenerator{Dist: ld, Lexicon: lex}.GenerateAll(smogBoard)
+		smogGenerator := NewWordSmogGenerator(dawg, smogBoard, ld)
+
+		for _, r := range racks {
+			rack := alphabet.RackFromString(r, alph)
+			smogGenerator.GenAll(rack, false)
+			smogPlays := map[string]*move.Move{}
+			for _, m := range scoringPlays(smogGenerator.Plays()) {
+				// Every play is made once, and forms only anagrams of words.
+				_, dupe := smogPlays[m.ShortDescription()]
+				is.True(!dupe)
+				smogPlays[m.ShortDescription()] = m
+				words, err := smogBoard.FormedWords(m)
+				is.NoErr(err)
+				for _, w := range words {
+					is.True(lex.HasWord(w))
+				}
+			}
+			// Plays of words that are spelled right are good too.
+			generator.GenAll(rack, false)
+			plays := scoringPlays(generator.Plays())
+			for _, m := range plays {
+				sm, ok := smogPlays[m.ShortDescription()]
+				is.True(ok)
+				is.Equal(sm.Score(), m.Score())
+			}
+			is.True(len(smogPlays) > len(plays))
+
+			all := NewTopPlaysRecorder(3, scoreEquity)
+			smogGenerator.GenAllWithRecorder(rack, false, all.Record)
+			shadow := NewTopPlaysRecorder(3, scoreEquity)
+			smogGenerator.GenAllWithShadow(rack, false, shadow, scoreBound)
+			is.Equal(len(shadow.Plays()), len(all.Plays()))
+			for i := range all.Plays() {
+				is.Equal(shadow.Plays()[i].Equity(), all.Plays()[i].Equity())
+			}
+		}
+	}
+}
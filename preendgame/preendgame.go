@@ -0,0 +1,513 @@
+// Package preendgame solves pre-endgames, where there are only a few tiles
+// left in the bag. For every candidate play that empties the bag, it tries
+// every possible draw; the unseen tiles that we don't draw are on the
+// opponent's rack, and the endgame that follows is solved with the
+// alphabeta solver.
+//
+// Plays that leave tiles in the bag, such as passes, are tried against
+// every draw and every set of tiles left in the bag. The opponent then
+// replies knowing both racks and what is in the bag, though not in what
+// order, with one of their highest scoring moves or a pass, until somebody
+// empties the bag and the endgame is solved. To keep this from going on,
+// only one more move that doesn't empty the bag is looked at after ours.
+package preendgame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/endgame/alphabeta"
+	"github.com/domino14/macondo/game"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/movegen"
+)
+
+const (
+	// MaxTilesInBag is the most tiles the bag may have. The number of
+	// draws, and so of endgames to solve, grows very quickly with it.
+	MaxTilesInBag = 3
+	// DefaultEndgamePlies is the depth of every endgame solve.
+	DefaultEndgamePlies = 4
+	// DefaultReplies is how many of their highest scoring moves the
+	// players pick from while there are tiles in the bag, besides passing.
+	DefaultReplies = 10
+	// MaxNonEmptyingMoves is how many moves that don't empty the bag are
+	// looked at after one of our plays that doesn't. After that, the
+	// player on turn has to empty the bag.
+	MaxNonEmptyingMoves = 1
+	// endgameTableMB is the size of the transposition table of each
+	// thread's endgame solver. The table is cleared for every solve.
+	endgameTableMB = 4
+)
+
+// Outcome is the result of a play for one draw.
+type Outcome struct {
+	// Draw is the tiles we draw after the play; the rest of the unseen
+	// tiles are on the opponent's rack.
+	Draw alphabet.MachineWord
+	// Bag is what is left in the bag after our draw, if the play doesn't
+	// empty it. These tiles aren't on the opponent's rack either.
+	Bag alphabet.MachineWord
+	// Ways is the number of ways these tiles can be drawn from the unseen
+	// tiles, which is what the outcome is weighted by.
+	Ways int
+	// Spread is our spread at the end of the game.
+	Spread float32
+}
+
+// PlayResult is how a play does against every possible draw.
+type PlayResult struct {
+	Play     *move.Move
+	Outcomes []*Outcome
+	// WinPct counts ties as half a win. Both it and AvgSpread are weighted
+	// by the number of ways each draw can happen.
+	WinPct    float64
+	AvgSpread float64
+}
+
+// Result is the result of solving a pre-endgame.
+type Result struct {
+	// Plays are sorted by win percentage, then by average spread.
+	Plays []*PlayResult
+	// Skipped are the candidate moves that can't be made with so few
+	// tiles in the bag, such as exchanges.
+	Skipped []*move.Move
+}
+
+// Summary returns a user-readable table of the plays.
+func (r *Result) Summary() string {
+	var ss strings.Builder
+	fmt.Fprintf(&ss, "%-20v%10v%12v%8v\n", "Play", "Win %", "Avg spread", "Draws")
+	for _, p := range r.Plays {
+		fmt.Fprintf(&ss, "%-20v%10.2f%12.2f%8v\n", p.Play.ShortDescription(),
+			100.0*p.WinPct, p.AvgSpread, len(p.Outcomes))
+	}
+	if len(r.Skipped) > 0 {
+		skipped := make([]string, len(r.Skipped))
+		for i, m := range r.Skipped {
+			skipped[i] = m.ShortDescription()
+		}
+		fmt.Fprintf(&ss, "Skipped moves that can't be made now: %v\n",
+			strings.Join(skipped, ", "))
+	}
+	return ss.String()
+}
+
+// Solver solves a pre-endgame from the point of view of the player on turn.
+type Solver struct {
+	origGame *game.Game
+
+	threads          int
+	endgamePlies     int
+	endgameTimeLimit time.Duration
+	replies          int
+}
+
+func (s *Solver) Init(game *game.Game) {
+	s.origGame = game
+	s.threads = int(math.Max(1, float64(runtime.NumCPU()-1)))
+	s.endgamePlies = DefaultEndgamePlies
+	s.replies = DefaultReplies
+}
+
+// SetThreads sets the number of threads to solve with; at least one.
+func (s *Solver) SetThreads(threads int) {
+	if threads < 1 {
+		threads = 1
+	}
+	s.threads = threads
+}
+
+// SetEndgamePlies sets the depth of every endgame solve.
+func (s *Solver) SetEndgamePlies(plies int) {
+	s.endgamePlies = plies
+}
+
+// SetReplies sets how many of their highest scoring moves the players pick
+// from while there are tiles in the bag, besides passing; at least one.
+func (s *Solver) SetReplies(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.replies = n
+}
+
+// SetEndgameTimeLimit bounds the time of every endgame solve; the deepest
+// search that finished in time is used. A limit of 0 means no limit.
+func (s *Solver) SetEndgameTimeLimit(d time.Duration) {
+	s.endgameTimeLimit = d
+}
+
+// draw is a set of tiles we could draw, and the number of ways to draw it.
+type draw struct {
+	tiles alphabet.MachineWord
+	ways  int
+}
+
+// draws returns every distinct set of n tiles that can be drawn from the
+// unseen tiles, given as a count of every letter.
+func draws(unseen []int, n int) []draw {
+	var ds []draw
+	var tiles alphabet.MachineWord
+	var rec func(letter, left, ways int)
+	rec = func(letter, left, ways int) {
+		if left == 0 {
+			ds = append(ds, draw{
+				tiles: append(alphabet.MachineWord(nil), tiles...),
+				ways:  ways,
+			})
+			return
+		}
+		if letter == len(unseen) {
+			return
+		}
+		start := len(tiles)
+		for k := 0; k <= left && k <= unseen[letter]; k++ {
+			if k > 0 {
+				tiles = append(tiles, alphabet.MachineLetter(letter))
+			}
+			rec(letter+1, left-k, ways*binomial(unseen[letter], k))
+		}
+		tiles = tiles[:start]
+	}
+	rec(0, n, 1)
+	return ds
+}
+
+func binomial(n, k int) int {
+	b := 1
+	for i := 0; i < k; i++ {
+		b = b * (n - i) / (i + 1)
+	}
+	return b
+}
+
+// job is one endgame to solve: a play followed by a draw.
+type job struct {
+	play    int
+	outcome *Outcome
+}
+
+// worker is what every thread solves with: its own copy of the game, and a
+// move generator and an endgame solver for that copy.
+type worker struct {
+	game   *game.Game
+	gen    movegen.MoveGenerator
+	solver *alphabeta.Solver
+}
+
+// Solve tries every play against every draw, and solves the endgame that
+// follows.
+func (s *Solver) Solve(ctx context.Context, plays []*move.Move) (*Result, error) {
+	g := s.origGame
+	inBag := g.Bag().TilesRemaining()
+	if inBag == 0 {
+		return nil, errors.New("the bag is empty; use the endgame solver instead")
+	}
+	if inBag > MaxTilesInBag {
+		return nil, fmt.Errorf("there are %v tiles in the bag; the pre-endgame solver handles at most %v",
+			inBag, MaxTilesInBag)
+	}
+	us := g.PlayerOnTurn()
+	opp := (us + 1) % g.NumPlayers()
+	ourRack := g.RackFor(us).Copy()
+
+	// From our point of view, the opponent's tiles are just as unseen as
+	// the ones in the bag.
+	unseen := make([]int, alphabet.MaxAlphabetSize+1)
+	for _, ml := range g.Bag().Peek() {
+		unseen[ml]++
+	}
+	for _, ml := range g.RackFor(opp).TilesOn() {
+		unseen[ml]++
+	}
+	possibleDraws := draws(unseen, inBag)
+
+	result := &Result{}
+	var jobs []job
+	for _, p := range plays {
+		if p.Action() != move.MoveTypePlay && p.Action() != move.MoveTypePass {
+			result.Skipped = append(result.Skipped, p)
+			continue
+		}
+		pr := &PlayResult{Play: p}
+		if p.TilesPlayed() >= inBag {
+			for _, d := range possibleDraws {
+				pr.Outcomes = append(pr.Outcomes, &Outcome{Draw: d.tiles, Ways: d.ways})
+			}
+		} else {
+			// We draw some of the unseen tiles, some more stay in the
+			// bag, and the opponent has the rest.
+			for _, d := range draws(unseen, p.TilesPlayed()) {
+				left := make([]int, len(unseen))
+				copy(left, unseen)
+				for _, ml := range d.tiles {
+					left[ml]--
+				}
+				for _, b := range draws(left, inBag-p.TilesPlayed()) {
+					pr.Outcomes = append(pr.Outcomes,
+						&Outcome{Draw: d.tiles, Bag: b.tiles, Ways: d.ways * b.ways})
+				}
+			}
+		}
+		for _, o := range pr.Outcomes {
+			jobs = append(jobs, job{play: len(result.Plays), outcome: o})
+		}
+		result.Plays = append(result.Plays, pr)
+	}
+	if len(result.Plays) == 0 {
+		return nil, errors.New("none of the moves can be made with so few tiles in the bag")
+	}
+
+	log.Debug().Int("threads", s.threads).Int("plays", len(result.Plays)).
+		Int("draws", len(possibleDraws)).Msg("solving-preendgame")
+
+	var next int64 = -1
+	eg := errgroup.Group{}
+	for t := 0; t < s.threads; t++ {
+		// Every thread has its own copy of the game, and the game needs to
+		// back up our play, the moves until the bag is empty, and every
+		// ply of the endgame.
+		gameCopy := g.Copy()
+		gameCopy.SetBackupMode(game.SimulationMode)
+		gameCopy.SetStateStackLength(2 + MaxNonEmptyingMoves + s.endgamePlies)
+		gen, err := movegen.NewGeneratorForGame(gameCopy)
+		if err != nil {
+			return nil, err
+		}
+		solver := &alphabeta.Solver{}
+		err = solver.Init(gen, gameCopy)
+		if err != nil {
+			return nil, err
+		}
+		solver.SetTimeLimit(s.endgameTimeLimit)
+		solver.SetTranspositionTableMB(endgameTableMB)
+		w := &worker{game: gameCopy, gen: gen, solver: solver}
+
+		eg.Go(func() error {
+			for {
+				j := atomic.AddInt64(&next, 1)
+				if j >= int64(len(jobs)) {
+					return nil
+				}
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				err := s.solveOutcome(ctx, w, result.Plays[jobs[j].play].Play,
+					jobs[j].outcome, ourRack, unseen, us, opp)
+				if err != nil {
+					return err
+				}
+			}
+		})
+	}
+	err := eg.Wait()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pr := range result.Plays {
+		pr.tally()
+	}
+	sort.SliceStable(result.Plays, func(i, j int) bool {
+		if result.Plays[i].WinPct == result.Plays[j].WinPct {
+			return result.Plays[i].AvgSpread > result.Plays[j].AvgSpread
+		}
+		return result.Plays[i].WinPct > result.Plays[j].WinPct
+	})
+	return result, nil
+}
+
+// solveOutcome gives the opponent every unseen tile that we don't draw
+// and that isn't left in the bag, makes the play, and solves the rest of
+// the game.
+func (s *Solver) solveOutcome(ctx context.Context, w *worker, play *move.Move,
+	o *Outcome, ourRack *alphabet.Rack, unseen []int, us, opp int) error {
+
+	g := w.game
+	oppTiles := make([]int, len(unseen))
+	copy(oppTiles, unseen)
+	for _, ml := range o.Draw {
+		oppTiles[ml]--
+	}
+	for _, ml := range o.Bag {
+		oppTiles[ml]--
+	}
+	racks := make([]*alphabet.Rack, 2)
+	racks[us] = ourRack.Copy()
+	racks[opp] = alphabet.NewRack(g.Alphabet())
+	for ml, ct := range oppTiles {
+		for i := 0; i < ct; i++ {
+			racks[opp].Add(alphabet.MachineLetter(ml))
+		}
+	}
+	// This leaves exactly the drawn tiles, and the ones that stay in the
+	// bag, in the bag.
+	err := g.SetRacksForBoth(racks)
+	if err != nil {
+		return err
+	}
+	if len(o.Bag) > 0 {
+		o.Spread, err = s.spreadAfterDraw(ctx, w, play, o.Draw, us, MaxNonEmptyingMoves)
+		return err
+	}
+	err = g.PlayMove(play, false, 0)
+	if err != nil {
+		return err
+	}
+	defer g.UnplayLastMove()
+
+	o.Spread, err = s.spreadFor(ctx, w, us, 0)
+	return err
+}
+
+// spreadFor returns player p's spread at the end of the game, with both
+// players making their best moves from here on. While there are tiles in
+// the bag, the player on turn picks from their candidate moves; at most
+// nonEmptying of the moves from here on may leave tiles in the bag.
+func (s *Solver) spreadFor(ctx context.Context, w *worker, p, nonEmptying int) (float32, error) {
+	g := w.game
+	if g.Playing() == pb.PlayState_GAME_OVER {
+		return float32(g.SpreadFor(p)), nil
+	}
+	onTurn := g.PlayerOnTurn()
+	inBag := g.Bag().TilesRemaining()
+	if inBag == 0 {
+		v, _, err := w.solver.Solve(ctx, s.endgamePlies)
+		if err != nil {
+			return 0, err
+		}
+		// The value is the spread the player on turn gains in the endgame.
+		spread := float32(g.SpreadFor(onTurn)) + v
+		if onTurn != p {
+			spread = -spread
+		}
+		return spread, nil
+	}
+	var best float32
+	found := false
+	for _, m := range s.candidates(w, onTurn) {
+		if m.TilesPlayed() < inBag && nonEmptying == 0 {
+			continue
+		}
+		v, err := s.spreadAfterMove(ctx, w, m, p, nonEmptying)
+		if err != nil {
+			return 0, err
+		}
+		if !found || (onTurn == p && v > best) || (onTurn != p && v < best) {
+			best = v
+			found = true
+		}
+	}
+	if !found {
+		// None of the candidates empty the bag, and we can't look any
+		// further; go with the spread as it is.
+		return float32(g.SpreadFor(p)), nil
+	}
+	return best, nil
+}
+
+// spreadAfterMove returns player p's final spread after the player on turn
+// makes the move. If the move doesn't empty the bag, this is the average
+// over what they could draw.
+func (s *Solver) spreadAfterMove(ctx context.Context, w *worker, m *move.Move,
+	p, nonEmptying int) (float32, error) {
+
+	g := w.game
+	bag := g.Bag().Peek()
+	if m.TilesPlayed() >= len(bag) {
+		err := g.PlayMove(m, false, 0)
+		if err != nil {
+			return 0, err
+		}
+		defer g.UnplayLastMove()
+		return s.spreadFor(ctx, w, p, nonEmptying)
+	}
+	inBag := make([]int, alphabet.MaxAlphabetSize+1)
+	for _, ml := range bag {
+		inBag[ml]++
+	}
+	var spread, ways float64
+	for _, d := range draws(inBag, m.TilesPlayed()) {
+		v, err := s.spreadAfterDraw(ctx, w, m, d.tiles, p, nonEmptying-1)
+		if err != nil {
+			return 0, err
+		}
+		spread += float64(d.ways) * float64(v)
+		ways += float64(d.ways)
+	}
+	return float32(spread / ways), nil
+}
+
+// spreadAfterDraw returns player p's final spread after the player on turn
+// makes the move, which doesn't empty the bag, and draws the given tiles.
+func (s *Solver) spreadAfterDraw(ctx context.Context, w *worker, m *move.Move,
+	drawn alphabet.MachineWord, p, nonEmptying int) (float32, error) {
+
+	g := w.game
+	mover := g.PlayerOnTurn()
+	other := (mover + 1) % g.NumPlayers()
+	otherRack := g.RackFor(other).Copy()
+	err := g.PlayMove(m, false, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer g.UnplayLastMove()
+	// Whatever the game drew, the mover keeps their leave and draws these
+	// tiles instead.
+	racks := make([]*alphabet.Rack, 2)
+	racks[mover] = alphabet.NewRack(g.Alphabet())
+	racks[mover].Set(append(append(alphabet.MachineWord(nil), m.Leave()...), drawn...))
+	racks[other] = otherRack
+	err = g.SetRacksForBoth(racks)
+	if err != nil {
+		return 0, err
+	}
+	return s.spreadFor(ctx, w, p, nonEmptying)
+}
+
+// candidates returns the moves the player picks from while there are tiles
+// in the bag: their highest scoring moves, and passing.
+func (s *Solver) candidates(w *worker, player int) []*move.Move {
+	rack := w.game.RackFor(player)
+	w.gen.GenAll(rack, false)
+	plays := w.gen.Plays()
+	var moves []*move.Move
+	for _, m := range plays {
+		if len(moves) == s.replies {
+			break
+		}
+		if m.Action() == move.MoveTypePlay {
+			moves = append(moves, m)
+		}
+	}
+	return append(moves, move.NewPassMove(rack.TilesOn(), w.game.Alphabet()))
+}
+
+func (pr *PlayResult) tally() {
+	var ways, wins, spread float64
+	for _, o := range pr.Outcomes {
+		w := float64(o.Ways)
+		ways += w
+		spread += w * float64(o.Spread)
+		if o.Spread > 0 {
+			wins += w
+		} else if o.Spread == 0 {
+			wins += w / 2
+		}
+	}
+	pr.WinPct = wins / ways
+	pr.AvgSpread = spread / ways
+}
@@ -0,0 +1,156 @@
+package preendgame
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/matryer/is"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/gaddagmaker"
+	"github.com/domino14/macondo/game"
+	"github.com/domino14/macondo/gcgio"
+	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/runner"
+)
+
+var DefaultConfig = config.DefaultConfig()
+
+func TestMain(m *testing.M) {
+	for _, lex := range []string{"NWL18"} {
+		gdgPath := filepath.Join(DefaultConfig.LexiconPath, "gaddag", lex+".gaddag")
+		if _, err := os.Stat(gdgPath); os.IsNotExist(err) {
+			gaddagmaker.GenerateGaddag(filepath.Join(DefaultConfig.LexiconPath, lex+".txt"), true, true)
+			err = os.Rename("out.gaddag", gdgPath)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func TestDraws(t *testing.T) {
+	is := is.New(t)
+	unseen := make([]int, alphabet.MaxAlphabetSize+1)
+	// AAB, and a blank.
+	unseen[0] = 2
+	unseen[1] = 1
+	unseen[alphabet.BlankMachineLetter] = 1
+
+	ds := draws(unseen, 2)
+	ways := map[string]int{}
+	total := 0
+	for _, d := range ds {
+		is.Equal(len(d.tiles), 2)
+		ways[d.tiles.String()] = d.ways
+		total += d.ways
+	}
+	// AA, AB, A? and B?, but not BB or ??.
+	is.Equal(len(ds), 4)
+	is.Equal(ways[alphabet.MachineWord{0, 0}.String()], 1)
+	is.Equal(ways[alphabet.MachineWord{0, 1}.String()], 2)
+	is.Equal(ways[alphabet.MachineWord{0, alphabet.BlankMachineLetter}.String()], 2)
+	is.Equal(ways[alphabet.MachineWord{1, alphabet.BlankMachineLetter}.String()], 1)
+	// Every way to pick 2 of the 4 tiles.
+	is.Equal(total, 6)
+}
+
+func TestSolveOneInTheBag(t *testing.T) {
+	is := is.New(t)
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/doug_v_emely.gcg")
+	is.NoErr(err)
+	boardLayout, ldName := game.HistoryToVariant(history)
+	rules, err := runner.NewAIGameRules(&DefaultConfig, boardLayout, history.Variant, "NWL18", ldName)
+	is.NoErr(err)
+	g, err := game.NewFromHistory(history, rules, 0)
+	is.NoErr(err)
+	gr, err := runner.NewAIGameRunnerFromGame(g, &DefaultConfig)
+	is.NoErr(err)
+	gr.SetChallengeRule(pb.ChallengeRule_DOUBLE)
+	// There is one tile in the bag on this turn.
+	is.NoErr(gr.PlayToTurn(24))
+	is.Equal(gr.Bag().TilesRemaining(), 1)
+
+	plays := gr.GenerateMoves(3)
+	s := &Solver{}
+	s.Init(&gr.Game)
+	s.SetThreads(0)
+	is.Equal(s.threads, 1)
+	s.SetThreads(2)
+	s.SetEndgamePlies(1)
+	res, err := s.Solve(context.Background(), plays)
+	is.NoErr(err)
+	is.Equal(len(res.Plays)+len(res.Skipped), len(plays))
+	is.True(len(res.Plays) > 0)
+
+	// 7 tiles on the opponent's rack and 1 in the bag.
+	unseen := 8
+	for i, pr := range res.Plays {
+		ways := 0
+		for _, o := range pr.Outcomes {
+			is.Equal(len(o.Draw), 1)
+			ways += o.Ways
+		}
+		is.Equal(ways, unseen)
+		is.True(pr.WinPct >= 0 && pr.WinPct <= 1)
+		if i > 0 {
+			is.True(pr.WinPct <= res.Plays[i-1].WinPct)
+		}
+	}
+	// The game is left alone.
+	is.Equal(gr.Bag().TilesRemaining(), 1)
+	is.Equal(gr.Turn(), 24)
+}
+
+func TestSolvePlaysThatLeaveTilesInTheBag(t *testing.T) {
+	is := is.New(t)
+	history, err := gcgio.ParseGCG(&DefaultConfig, "../gcgio/testdata/doug_v_emely.gcg")
+	is.NoErr(err)
+	boardLayout, ldName := game.HistoryToVariant(history)
+	rules, err := runner.NewAIGameRules(&DefaultConfig, boardLayout, history.Variant, "NWL18", ldName)
+	is.NoErr(err)
+	g, err := game.NewFromHistory(history, rules, 0)
+	is.NoErr(err)
+	gr, err := runner.NewAIGameRunnerFromGame(g, &DefaultConfig)
+	is.NoErr(err)
+	gr.SetChallengeRule(pb.ChallengeRule_DOUBLE)
+	is.NoErr(gr.PlayToTurn(24))
+	is.Equal(gr.Bag().TilesRemaining(), 1)
+
+	rack := gr.RackFor(gr.PlayerOnTurn())
+	pass := move.NewPassMove(rack.TilesOn(), gr.Alphabet())
+	plays := append(gr.GenerateMoves(1), pass)
+	s := &Solver{}
+	s.Init(&gr.Game)
+	s.SetThreads(2)
+	s.SetEndgamePlies(1)
+	s.SetReplies(2)
+	res, err := s.Solve(context.Background(), plays)
+	is.NoErr(err)
+	is.Equal(len(res.Skipped), 0)
+	is.Equal(len(res.Plays), 2)
+
+	for _, pr := range res.Plays {
+		if pr.Play != pass {
+			continue
+		}
+		// We draw nothing, and the tile left in the bag is any of the 8
+		// unseen tiles.
+		ways := 0
+		for _, o := range pr.Outcomes {
+			is.Equal(len(o.Draw), 0)
+			is.Equal(len(o.Bag), 1)
+			ways += o.Ways
+		}
+		is.Equal(ways, 8)
+		is.True(pr.WinPct >= 0 && pr.WinPct <= 1)
+	}
+	// The game is left alone.
+	is.Equal(gr.Bag().TilesRemaining(), 1)
+	is.Equal(gr.Turn(), 24)
+}
@@ -26,9 +26,8 @@ type GameRunner struct {
 
 func NewGameRunner(conf *config.Config, opts *GameOptions, players []*pb.PlayerInfo) (*GameRunner, error) {
 	opts.SetDefaults(conf)
-	rules, err := game.NewBasicGameRules(
-		conf, board.CrosswordGameBoard,
-		opts.Lexicon.Distribution)
+	boardLayout, ldName := opts.BoardLayout()
+	rules, err := game.NewBasicGameRules(conf, boardLayout, ldName)
 	if err != nil {
 		return nil, err
 	}
@@ -113,9 +112,8 @@ type AIGameRunner struct {
 
 func NewAIGameRunner(conf *config.Config, opts *GameOptions, players []*pb.PlayerInfo) (*AIGameRunner, error) {
 	opts.SetDefaults(conf)
-	rules, err := NewAIGameRules(
-		conf, board.CrosswordGameBoard,
-		opts.Lexicon.Name, opts.Lexicon.Distribution)
+	boardLayout, ldName := opts.BoardLayout()
+	rules, err := NewAIGameRules(conf, boardLayout, opts.Variant, opts.Lexicon.Name, ldName)
 	if err != nil {
 		return nil, err
 	}
@@ -142,17 +140,12 @@ func addAIFields(g *GameRunner, conf *config.Config) (*AIGameRunner, error) {
 		return nil, err
 	}
 
-	gdObj, err := cache.Load(conf, "gaddag:"+g.LexiconName(), gaddag.CacheLoadFunc)
+	gen, err := movegen.NewGeneratorForGame(&g.Game)
 	if err != nil {
 		return nil, err
 	}
-	gd, ok := gdObj.(*gaddag.SimpleGaddag)
-	if !ok {
-		return nil, errors.New("type-assertion failed; gaddag")
-	}
 
 	aiplayer := player.NewRawEquityPlayer(strategy)
-	gen := movegen.NewGordonGenerator(gd, g.Board(), g.Bag().LetterDistribution())
 
 	ret := &AIGameRunner{*g, aiplayer, gen}
 	return ret, nil
@@ -183,7 +176,7 @@ func (g *AIGameRunner) AIPlayer() player.AIPlayer {
 	return g.aiplayer
 }
 
-func NewAIGameRules(cfg *config.Config, boardLayout []string,
+func NewAIGameRules(cfg *config.Config, boardLayout []string, variant string,
 	lexiconName string, letterDistributionName string) (*game.GameRules, error) {
 	dist, err := cache.Load(cfg, "letterdist:"+letterDistributionName,
 		alphabet.CacheLoadFunc)
@@ -194,6 +187,25 @@ func NewAIGameRules(cfg *config.Config, boardLayout []string,
 	if !ok {
 		return nil, errors.New("type-assertion failed (letterDistribution)")
 	}
+	board := board.MakeBoard(boardLayout)
+	if variant == game.VariantWordSmog {
+		// Words only need to be anagrams of words in the lexicon, so
+		// look them up by alphagram.
+		dawgObj, err := cache.Load(cfg, "alphagrams:"+lexiconName, gaddag.AlphagramCacheLoadFunc)
+		if err != nil {
+			return nil, err
+		}
+		dawg, ok := dawgObj.(*gaddag.SimpleDawg)
+		if !ok {
+			return nil, errors.New("type-assertion failed; dawg")
+		}
+		lex := gaddag.AlphagramLexicon{GenericDawg: dawg}
+		cset := cross_set.LexiconCrossSetGenerator{
+			Lexicon: lex,
+			Dist:    distLD,
+		}
+		return game.NewGameRules(cfg, distLD, board, lex, cset, variant), nil
+	}
 	gdObj, err := cache.Load(cfg, "gaddag:"+lexiconName, gaddag.CacheLoadFunc)
 	if err != nil {
 		return nil, err
@@ -202,12 +214,11 @@ func NewAIGameRules(cfg *config.Config, boardLayout []string,
 	if !ok {
 		return nil, errors.New("type-assertion failed; gaddag")
 	}
-	board := board.MakeBoard(boardLayout)
 	cset := cross_set.GaddagCrossSetGenerator{
 		Gaddag: gd,
 		Dist:   distLD,
 	}
 	lex := gaddag.Lexicon{gd}
-	rules := game.NewGameRules(cfg, distLD, board, lex, cset)
+	rules := game.NewGameRules(cfg, distLD, board, lex, cset, variant)
 	return rules, nil
 }
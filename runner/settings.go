@@ -3,8 +3,11 @@ package runner
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/domino14/macondo/board"
 	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/game"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
 	"github.com/rs/zerolog/log"
 )
@@ -20,6 +23,7 @@ func (lex *Lexicon) ToDisplayString() string {
 
 type GameOptions struct {
 	Lexicon         *Lexicon
+	Variant         string
 	ChallengeRule   pb.ChallengeRule
 	FirstIsAssigned bool
 	GoesFirst       int
@@ -30,6 +34,9 @@ func (opts *GameOptions) SetDefaults(config *config.Config) {
 		opts.Lexicon = &Lexicon{config.DefaultLexicon, "english"}
 		log.Info().Msgf("using default lexicon %v", opts.Lexicon)
 	}
+	if opts.Variant == "" {
+		opts.Variant = game.VariantCrosswordGame
+	}
 }
 
 func (opts *GameOptions) SetLexicon(fields []string) error {
@@ -47,6 +54,32 @@ func (opts *GameOptions) SetLexicon(fields []string) error {
 	return nil
 }
 
+func (opts *GameOptions) SetVariant(variant string) error {
+	variants := []string{game.VariantCrosswordGame, game.VariantSuperCrosswordGame,
+		game.VariantWordSmog}
+	for _, v := range variants {
+		if strings.EqualFold(variant, v) {
+			opts.Variant = v
+			return nil
+		}
+	}
+	return fmt.Errorf("valid variants are %v", strings.Join(variants, ", "))
+}
+
+// BoardLayout returns the board layout and the letter distribution to
+// play the variant with. The super variant is played with twice the tiles
+// of the English distribution, if that is the one set.
+func (opts *GameOptions) BoardLayout() ([]string, string) {
+	if opts.Variant == game.VariantSuperCrosswordGame {
+		dist := opts.Lexicon.Distribution
+		if strings.EqualFold(dist, "english") {
+			dist = "englishsuper"
+		}
+		return board.SuperCrosswordGameBoard, dist
+	}
+	return board.CrosswordGameBoard, opts.Lexicon.Distribution
+}
+
 func (opts *GameOptions) SetChallenge(rule string) error {
 	val, err := ParseChallengeRule(rule)
 	if err != nil {
@@ -1,19 +1,26 @@
 package shell
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/domino14/macondo/analysis"
 	"github.com/domino14/macondo/automatic"
 	"github.com/domino14/macondo/endgame/alphabeta"
 	"github.com/domino14/macondo/game"
 	"github.com/domino14/macondo/gcgio"
 	pb "github.com/domino14/macondo/gen/api/proto/macondo"
+	"github.com/domino14/macondo/inference"
+	"github.com/domino14/macondo/movegen"
+	"github.com/domino14/macondo/preendgame"
 	"github.com/domino14/macondo/runner"
 )
 
@@ -140,9 +147,20 @@ func (sc *ShellController) generate(cmd *shellcmd) (*Response, error) {
 	}
 	if sc.game == nil {
 		return nil, errors.New("please load or create a game first")
-	} else {
-		sc.genMovesAndDisplay(numPlays)
 	}
+	constraints, err := genConstraints(cmd.options, sc.game.Alphabet(), sc.game.Board().Dim())
+	if err != nil {
+		return nil, err
+	}
+	if constraints != nil {
+		cgen, ok := sc.gen.(movegen.ConstrainedMoveGenerator)
+		if !ok {
+			return nil, errors.New("the move generator doesn't take constraints")
+		}
+		cgen.SetConstraints(constraints)
+		defer cgen.SetConstraints(nil)
+	}
+	sc.genMovesAndDisplay(numPlays)
 	return nil, nil
 }
 
@@ -151,7 +169,105 @@ func (sc *ShellController) autoplay(cmd *shellcmd) (*Response, error) {
 }
 
 func (sc *ShellController) sim(cmd *shellcmd) (*Response, error) {
-	return nil, sc.handleSim(cmd.args)
+	return nil, sc.handleSim(cmd.args, cmd.options)
+}
+
+func (sc *ShellController) infer(cmd *shellcmd) (*Response, error) {
+	if sc.game == nil {
+		return nil, errors.New("please load a game first with the `load` command")
+	}
+	if sc.simmer.IsSimming() {
+		return nil, errors.New("simming already, please do a `sim stop` first")
+	}
+	if len(cmd.args) > 0 && cmd.args[0] == "clear" {
+		sc.simmer.SetOppInference(nil)
+		return msg("sims will give the opponent random racks"), nil
+	}
+	inferrer := &inference.Inferrer{}
+	inferrer.Init(&sc.game.Game, sc.game.AIPlayer())
+	if samples, ok := cmd.options["samples"]; ok {
+		n, err := strconv.Atoi(samples)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.SetSamples(n)
+	}
+	if tolerance, ok := cmd.options["tolerance"]; ok {
+		t, err := strconv.ParseFloat(tolerance, 64)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.SetTolerance(t)
+	}
+	if threads, ok := cmd.options["threads"]; ok {
+		n, err := strconv.Atoi(threads)
+		if err != nil {
+			return nil, err
+		}
+		inferrer.SetThreads(n)
+	}
+	inf, err := inferrer.Infer(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if inf.Accepted == 0 {
+		return nil, errors.New("no rack was consistent with the opponent's play; try a higher tolerance")
+	}
+	sc.simmer.SetOppInference(inf)
+	return msg(inf.Summary(20) + "Sims will now draw the opponent's leave from these."), nil
+}
+
+func (sc *ShellController) analyze(cmd *shellcmd) (*Response, error) {
+	if sc.game == nil {
+		return nil, errors.New("please load a game first with the `load` command")
+	}
+	if sc.simmer.IsSimming() {
+		return nil, errors.New("simming already, please do a `sim stop` first")
+	}
+	analyzer := analysis.NewAnalyzer(sc.config)
+	intOptions := []struct {
+		name string
+		set  func(int)
+	}{
+		{"candidates", analyzer.SetCandidates},
+		{"plies", analyzer.SetPlies},
+		{"iterations", analyzer.SetIterations},
+		{"threads", analyzer.SetThreads},
+		{"endgame", analyzer.SetEndgamePlies},
+	}
+	for _, opt := range intOptions {
+		if val, ok := cmd.options[opt.name]; ok {
+			n, err := strconv.Atoi(val)
+			if err != nil {
+				return nil, err
+			}
+			opt.set(n)
+		}
+	}
+	if threshold, ok := cmd.options["threshold"]; ok {
+		t, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			return nil, err
+		}
+		analyzer.SetMistakeThreshold(t)
+	}
+	sc.showMessage("Analyzing the game, this can take a while...")
+	ga, err := analyzer.Analyze(context.Background(), sc.game.History())
+	if err != nil {
+		return nil, err
+	}
+	if len(cmd.args) > 0 {
+		contents, err := ga.GCG()
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(cmd.args[0], []byte(contents), 0644)
+		if err != nil {
+			return nil, err
+		}
+		sc.showMessage("annotated gcg written to " + cmd.args[0])
+	}
+	return msg(ga.Summary()), nil
 }
 
 func (sc *ShellController) add(cmd *shellcmd) (*Response, error) {
@@ -200,9 +316,23 @@ func (sc *ShellController) challenge(cmd *shellcmd) (*Response, error) {
 }
 
 func (sc *ShellController) endgame(cmd *shellcmd) (*Response, error) {
+	if len(cmd.args) > 0 && cmd.args[0] == "stop" {
+		if !sc.isSolving() {
+			return nil, errors.New("no running endgame to stop")
+		}
+		// The solver finishes up and shows its best result so far.
+		sc.endgameCancel()
+		return nil, nil
+	}
+	if len(cmd.args) > 0 && cmd.args[0] == "export" {
+		return sc.exportEndgameTree(cmd)
+	}
 	if sc.game == nil {
 		return nil, errors.New("please load a game first with the `load` command")
 	}
+	if sc.isSolving() {
+		return nil, errors.New("solving already, please do an `endgame stop` first")
+	}
 	plies, deepening, simpleEval, disablePruning, err := endgameArgs(cmd.args)
 	if err != nil {
 		return nil, err
@@ -211,31 +341,136 @@ func (sc *ShellController) endgame(cmd *shellcmd) (*Response, error) {
 		"plies %v, deepening %v, simpleEval %v, pruningDisabled %v",
 		plies, deepening, simpleEval, disablePruning))
 
-	sc.game.SetStateStackLength(plies)
-	sc.game.SetBackupMode(game.SimulationMode)
+	// Solve with a copy of the game, so that the shell can be used while
+	// the solver is running.
+	g := sc.game.Game.Copy()
+	g.SetStateStackLength(plies)
+	g.SetBackupMode(game.SimulationMode)
+	gen, err := movegen.NewGeneratorForGame(g)
+	if err != nil {
+		return nil, err
+	}
 
+	newSolver, err := endgameSolverFunc(cmd.options["solver"])
+	if err != nil {
+		return nil, err
+	}
 	// clear out the last value of this endgame node; gc should
 	// delete the tree.
 	sc.curEndgameNode = nil
-	sc.endgameSolver = new(alphabeta.Solver)
-	err = sc.endgameSolver.Init(sc.gen, &sc.game.Game)
+	sc.endgameSolver = newSolver()
+	err = sc.endgameSolver.Init(gen, g)
 	if err != nil {
 		return nil, err
 	}
-	sc.endgameSolver.SetIterativeDeepening(deepening)
-	sc.endgameSolver.SetSimpleEvaluator(simpleEval)
-	sc.endgameSolver.SetPruningDisabled(disablePruning)
+	if ab, ok := sc.endgameSolver.(*alphabeta.Solver); ok {
+		err = setAlphabetaOptions(ab, cmd.options, deepening, simpleEval, disablePruning)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	sc.showMessage(sc.game.ToDisplayText())
+	sc.startEndgame(plies)
+	return nil, nil
+}
 
-	val, seq, err := sc.endgameSolver.Solve(plies)
-	if err != nil {
-		return nil, err
+// setAlphabetaOptions sets up the alphabeta solver with the endgame
+// command's arguments and options.
+func setAlphabetaOptions(s *alphabeta.Solver, options map[string]string,
+	deepening, simpleEval, disablePruning bool) error {
+
+	s.SetIterativeDeepening(deepening)
+	s.SetSimpleEvaluator(simpleEval)
+	s.SetPruningDisabled(disablePruning)
+	if mb, ok := options["ttable"]; ok {
+		size, err := strconv.Atoi(mb)
+		if err != nil {
+			return err
+		}
+		s.SetTranspositionTableMB(size)
+	}
+	if t, ok := options["threads"]; ok {
+		threads, err := strconv.Atoi(t)
+		if err != nil {
+			return err
+		}
+		s.SetThreads(threads)
+	}
+	if t, ok := options["time"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return err
+		}
+		s.SetTimeLimit(d)
+	}
+	if r, ok := options["rank"]; ok {
+		k := -1
+		if r != "all" {
+			var err error
+			k, err = strconv.Atoi(r)
+			if err != nil {
+				return err
+			}
+			if k < 1 {
+				return errors.New("rank must be a positive number or all")
+			}
+		}
+		s.SetRankedMoves(k)
 	}
-	// And turn off simulation mode again.
-	sc.game.SetBackupMode(game.InteractiveGameplayMode)
-	sc.showMessage(fmt.Sprintf("Best sequence has a spread difference of %v", val))
-	sc.printEndgameSequence(seq)
+	return nil
+}
+
+func (sc *ShellController) preendgame(cmd *shellcmd) (*Response, error) {
+	if len(cmd.args) > 0 && cmd.args[0] == "stop" {
+		if !sc.isPreendgameSolving() {
+			return nil, errors.New("no running pre-endgame to stop")
+		}
+		sc.preendgameCancel()
+		return nil, nil
+	}
+	if sc.game == nil {
+		return nil, errors.New("please load a game first with the `load` command")
+	}
+	if len(sc.curPlayList) == 0 {
+		return nil, errors.New("please generate some plays first")
+	}
+	if sc.isPreendgameSolving() {
+		return nil, errors.New("solving already, please do a `preendgame stop` first")
+	}
+	// Solve with a copy of the game, so that the shell can be used while
+	// the solver is running.
+	solver := &preendgame.Solver{}
+	solver.Init(sc.game.Game.Copy())
+	if len(cmd.args) > 0 {
+		plies, err := strconv.Atoi(cmd.args[0])
+		if err != nil {
+			return nil, err
+		}
+		solver.SetEndgamePlies(plies)
+	}
+	if threads, ok := cmd.options["threads"]; ok {
+		n, err := strconv.Atoi(threads)
+		if err != nil {
+			return nil, err
+		}
+		solver.SetThreads(n)
+	}
+	if t, ok := cmd.options["time"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return nil, err
+		}
+		solver.SetEndgameTimeLimit(d)
+	}
+	if replies, ok := cmd.options["replies"]; ok {
+		n, err := strconv.Atoi(replies)
+		if err != nil {
+			return nil, err
+		}
+		solver.SetReplies(n)
+	}
+	sc.startPreendgame(solver, sc.curPlayList)
 	return nil, nil
 }
 
@@ -257,6 +492,9 @@ func (sc *ShellController) setMode(cmd *shellcmd) (*Response, error) {
 	if err != nil {
 		return nil, err
 	}
+	if m == EndgameDebugMode && sc.isSolving() {
+		return nil, errors.New("please wait for the endgame to finish, or do an `endgame stop`")
+	}
 	sc.curMode = m
 	return msg("Setting current mode to " + mode), nil
 }
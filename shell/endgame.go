@@ -0,0 +1,196 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/domino14/macondo/endgame"
+	"github.com/domino14/macondo/endgame/alphabeta"
+	"github.com/domino14/macondo/endgame/greedy"
+	"github.com/domino14/macondo/move"
+	"github.com/domino14/macondo/preendgame"
+)
+
+// endgameSolverFunc returns the function that makes the endgame solver
+// with the given name; the alphabeta solver if no name is given.
+func endgameSolverFunc(name string) (endgame.NewSolverFunc, error) {
+	switch name {
+	case "", "alphabeta":
+		return alphabeta.NewSolver, nil
+	case "greedy":
+		return greedy.NewSolver, nil
+	}
+	return nil, fmt.Errorf("unknown endgame solver %v; it must be alphabeta or greedy", name)
+}
+
+func (sc *ShellController) isSolving() bool {
+	if sc.endgameDone == nil {
+		return false
+	}
+	select {
+	case <-sc.endgameDone:
+		return false
+	default:
+		return true
+	}
+}
+
+func (sc *ShellController) showEndgameProgress(p alphabeta.Progress) {
+	moves := make([]string, len(p.Sequence))
+	for i, m := range p.Sequence {
+		moves[i] = m.ShortDescription()
+	}
+	sc.showMessage(fmt.Sprintf("%v plies (%v nodes, %v): %v; %v",
+		p.Depth, p.Nodes, p.Elapsed.Round(1e6), p.Value, strings.Join(moves, ", ")))
+}
+
+// startEndgame solves the endgame in the background. The alphabeta solver
+// shows the best sequence so far every time it finishes a depth.
+func (sc *ShellController) startEndgame(plies int) {
+	var ctx context.Context
+	ctx, sc.endgameCancel = context.WithCancel(context.Background())
+	sc.endgameDone = make(chan struct{})
+	ab, isAlphabeta := sc.endgameSolver.(*alphabeta.Solver)
+	if isAlphabeta {
+		ab.SetProgressCallback(sc.showEndgameProgress)
+	}
+	sc.showMessage("Solving started. Do `endgame stop` to stop early and get the best result so far")
+
+	solver, cancel, done := sc.endgameSolver, sc.endgameCancel, sc.endgameDone
+	go func() {
+		defer close(done)
+		defer cancel()
+		val, seq, err := solver.Solve(ctx, plies)
+		if err != nil {
+			sc.showError(err)
+			return
+		}
+		stats := solver.Stats()
+		sc.showMessage(fmt.Sprintf("Best sequence has a spread difference of %v (%v plies, %v nodes, %v)",
+			val, stats.Depth, stats.Nodes, stats.Elapsed.Round(1e6)))
+		sc.printEndgameSequence(seq)
+		if isAlphabeta {
+			sc.printRankedMoves(ab.RankedMoves())
+		}
+	}()
+}
+
+func (sc *ShellController) isPreendgameSolving() bool {
+	if sc.preendgameDone == nil {
+		return false
+	}
+	select {
+	case <-sc.preendgameDone:
+		return false
+	default:
+		return true
+	}
+}
+
+// startPreendgame solves the pre-endgame for the plays in the background.
+func (sc *ShellController) startPreendgame(solver *preendgame.Solver, plays []*move.Move) {
+	var ctx context.Context
+	ctx, sc.preendgameCancel = context.WithCancel(context.Background())
+	sc.preendgameDone = make(chan struct{})
+	sc.showMessage("Solving started. Do `preendgame stop` to stop early")
+
+	cancel, done := sc.preendgameCancel, sc.preendgameDone
+	go func() {
+		defer close(done)
+		defer cancel()
+		res, err := solver.Solve(ctx, plays)
+		if ctx.Err() == context.Canceled {
+			sc.showMessage("Pre-endgame stopped.")
+			return
+		}
+		if err != nil {
+			sc.showError(err)
+			return
+		}
+		sc.showMessage(res.Summary())
+	}()
+}
+
+// printRankedMoves shows every ranked move with the final spread it leads
+// to, and its best sequence.
+func (sc *ShellController) printRankedMoves(ranked []alphabeta.RankedMove) {
+	if len(ranked) == 0 {
+		return
+	}
+	sc.showMessage("Ranked moves:")
+	for idx, r := range ranked {
+		moves := make([]string, len(r.Sequence))
+		for i, m := range r.Sequence {
+			moves[i] = m.ShortDescription()
+		}
+		spread := fmt.Sprintf("%v", r.FinalSpread)
+		if !r.Exact {
+			spread = "<= " + spread
+		}
+		sc.showMessage(fmt.Sprintf("%d) %v, %v, %v", idx+1, r.Move.ShortDescription(),
+			spread, strings.Join(moves, " ")))
+	}
+}
+
+// exportEndgameTree writes the search tree of the last endgame solve to a
+// file, as JSON or as a Graphviz graph, depending on the file's extension.
+func (sc *ShellController) exportEndgameTree(cmd *shellcmd) (*Response, error) {
+	if len(cmd.args) < 2 {
+		return nil, errors.New("please give the file to export the tree to")
+	}
+	if sc.isSolving() {
+		return nil, errors.New("solving, please wait or do an `endgame stop` first")
+	}
+	ab, ok := sc.endgameSolver.(*alphabeta.Solver)
+	if !ok {
+		return nil, errors.New("only the alphabeta solver has a tree to export")
+	}
+	opts := alphabeta.TreeOptions{}
+	if d, ok := cmd.options["depth"]; ok {
+		depth, err := strconv.Atoi(d)
+		if err != nil {
+			return nil, err
+		}
+		opts.MaxDepth = depth
+	}
+	if t, ok := cmd.options["tree"]; ok {
+		switch t {
+		case "all":
+		case "pv":
+			opts.PVOnly = true
+		default:
+			return nil, errors.New("tree must be all or pv")
+		}
+	}
+	tree := ab.SearchTree(opts)
+	if tree == nil {
+		return nil, errors.New("please solve an endgame first")
+	}
+
+	filename := cmd.args[1]
+	write := alphabeta.WriteTreeDot
+	switch filepath.Ext(filename) {
+	case ".json":
+		write = alphabeta.WriteTreeJSON
+	case ".dot", ".gv":
+	default:
+		return nil, errors.New("the file must end in .dot, .gv or .json")
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	err = write(f, tree)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return msg("Exported the search tree to " + filename), nil
+}
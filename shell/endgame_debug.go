@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+
+	"github.com/domino14/macondo/endgame/alphabeta"
 )
 
 func (sc *ShellController) endgameDebugModeSwitch(line string, sig chan os.Signal) error {
@@ -35,7 +37,11 @@ func (sc *ShellController) endgameDebugModeSwitch(line string, sig chan os.Signa
 	case "l":
 		// List the current level of nodes
 		if sc.curEndgameNode == nil {
-			sc.curEndgameNode = sc.endgameSolver.RootNode()
+			ab, ok := sc.endgameSolver.(*alphabeta.Solver)
+			if !ok {
+				return errors.New("only the alphabeta solver has a tree to debug")
+			}
+			sc.curEndgameNode = ab.RootNode()
 		}
 
 		sc.showMessage(sc.curEndgameNode.String())
@@ -0,0 +1,85 @@
+package shell
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/movegen"
+)
+
+var reSquare = regexp.MustCompile(`^(?:(\d+)([A-Z])|([A-Z])(\d+))$`)
+
+// genConstraints makes the move generator constraints out of the options
+// of the gen command, or returns nil if there aren't any.
+func genConstraints(options map[string]string, alph *alphabet.Alphabet, dim int) (
+	*movegen.Constraints, error) {
+
+	if len(options) == 0 {
+		return nil, nil
+	}
+	c := &movegen.Constraints{}
+	for opt, val := range options {
+		var err error
+		switch opt {
+		case "through":
+			for _, sq := range strings.Split(val, ",") {
+				row, col, err := parseSquare(sq, dim)
+				if err != nil {
+					return nil, err
+				}
+				c.Squares = append(c.Squares, movegen.Coord{Row: row, Col: col})
+			}
+		case "rows":
+			for _, r := range strings.Split(val, ",") {
+				row, err := strconv.Atoi(r)
+				if err != nil || row < 1 || row > dim {
+					return nil, fmt.Errorf("%v is not a row", r)
+				}
+				c.Rows = append(c.Rows, row-1)
+			}
+		case "cols":
+			for _, col := range strings.Split(strings.ToUpper(val), ",") {
+				if len(col) != 1 || col[0] < 'A' || int(col[0]-'A') >= dim {
+					return nil, fmt.Errorf("%v is not a column", col)
+				}
+				c.Columns = append(c.Columns, int(col[0]-'A'))
+			}
+		case "using":
+			c.RequiredTiles, err = alphabet.ToMachineWord(strings.ToUpper(val), alph)
+		case "without":
+			c.ForbiddenTiles, err = alphabet.ToMachineWord(strings.ToUpper(val), alph)
+		case "mintiles":
+			c.MinTilesPlayed, err = strconv.Atoi(val)
+		case "word":
+			c.WordPattern, err = regexp.Compile("(?i)" + val)
+		default:
+			return nil, fmt.Errorf("unknown gen option %v", opt)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// parseSquare parses a square given like the coordinates of a play, such
+// as 8H or H8; the direction doesn't matter.
+func parseSquare(sq string, dim int) (int, int, error) {
+	m := reSquare.FindStringSubmatch(strings.ToUpper(sq))
+	if m == nil {
+		return 0, 0, fmt.Errorf("%v is not a square", sq)
+	}
+	rowStr, colStr := m[1], m[2]
+	if rowStr == "" {
+		rowStr, colStr = m[4], m[3]
+	}
+	row, _ := strconv.Atoi(rowStr)
+	col := int(colStr[0] - 'A')
+	if row < 1 || row > dim || col >= dim {
+		return 0, 0, fmt.Errorf("%v is not on the board", sq)
+	}
+	return row - 1, col, nil
+}
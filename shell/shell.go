@@ -22,6 +22,7 @@ import (
 	"github.com/domino14/macondo/alphabet"
 	"github.com/domino14/macondo/automatic"
 	"github.com/domino14/macondo/config"
+	"github.com/domino14/macondo/endgame"
 	"github.com/domino14/macondo/endgame/alphabeta"
 	"github.com/domino14/macondo/game"
 	"github.com/domino14/macondo/gcgio"
@@ -61,6 +62,8 @@ func (opts *ShellOptions) Show(key string) (bool, string) {
 	switch key {
 	case "lexicon":
 		return true, opts.Lexicon.ToDisplayString()
+	case "variant":
+		return true, opts.Variant
 	case "lower":
 		return true, fmt.Sprintf("%v", opts.lowercaseMoves)
 	case "challenge":
@@ -72,7 +75,7 @@ func (opts *ShellOptions) Show(key string) (bool, string) {
 }
 
 func (opts *ShellOptions) ToDisplayText() string {
-	keys := []string{"lexicon", "challenge", "lower"}
+	keys := []string{"lexicon", "variant", "challenge", "lower"}
 	out := strings.Builder{}
 	out.WriteString("Settings:\n")
 	for _, key := range keys {
@@ -96,7 +99,7 @@ type ShellController struct {
 	simCtx        context.Context
 	simCancel     context.CancelFunc
 	simTicker     *time.Ticker
-	simTickerDone chan bool
+	simTickerDone chan struct{}
 	simLogFile    *os.File
 
 	gameRunnerCtx     context.Context
@@ -107,9 +110,14 @@ type ShellController struct {
 	curTurnNum     int
 	gen            movegen.MoveGenerator
 	curMode        Mode
-	endgameSolver  *alphabeta.Solver
+	endgameSolver  endgame.Solver
+	endgameCancel  context.CancelFunc
+	endgameDone    chan struct{}
 	curEndgameNode *alphabeta.GameNode
 	curPlayList    []*move.Move
+
+	preendgameCancel context.CancelFunc
+	preendgameDone   chan struct{}
 }
 
 type Mode int
@@ -174,6 +182,14 @@ func (sc *ShellController) Set(key string, args []string) (string, error) {
 			err = sc.options.SetLexicon(args)
 			_, ret = sc.options.Show("lexicon")
 		}
+	case "variant":
+		if sc.IsPlaying() {
+			msg := "Cannot change the variant while a game is active"
+			err = errors.New(msg)
+		} else {
+			err = sc.options.SetVariant(args[0])
+			_, ret = sc.options.Show("variant")
+		}
 	case "challenge":
 		err = sc.options.SetChallenge(args[0])
 		_, ret = sc.options.Show("challenge")
@@ -278,6 +294,11 @@ func (sc *ShellController) loadGCG(args []string) error {
 			return err
 		}
 	}
+	return sc.loadHistory(history)
+}
+
+// loadHistory sets up a new game from the history, at the first turn.
+func (sc *ShellController) loadHistory(history *pb.GameHistory) error {
 	log.Debug().Msgf("Loaded game repr; players: %v", history.Players)
 	lexicon := history.Lexicon
 	if lexicon == "" {
@@ -286,7 +307,7 @@ func (sc *ShellController) loadGCG(args []string) error {
 			lexicon)
 	}
 	boardLayout, ldName := game.HistoryToVariant(history)
-	rules, err := runner.NewAIGameRules(sc.config, boardLayout, lexicon, ldName)
+	rules, err := runner.NewAIGameRules(sc.config, boardLayout, history.Variant, lexicon, ldName)
 	if err != nil {
 		return err
 	}
@@ -661,6 +682,8 @@ func (sc *ShellController) standardModeSwitch(line string, sig chan os.Signal) (
 		return sc.autoplay(cmd)
 	case "sim":
 		return sc.sim(cmd)
+	case "infer":
+		return sc.infer(cmd)
 	case "add":
 		return sc.add(cmd)
 	case "challenge":
@@ -675,12 +698,16 @@ func (sc *ShellController) standardModeSwitch(line string, sig chan os.Signal) (
 		return sc.list(cmd)
 	case "endgame":
 		return sc.endgame(cmd)
+	case "preendgame":
+		return sc.preendgame(cmd)
 	case "mode":
 		return sc.setMode(cmd)
 	case "export":
 		return sc.export(cmd)
 	case "autoanalyze":
 		return sc.autoAnalyze(cmd)
+	case "analyze":
+		return sc.analyze(cmd)
 	default:
 		msg := fmt.Sprintf("command %v not found", strconv.Quote(cmd.cmd))
 		log.Info().Msg(msg)
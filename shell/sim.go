@@ -4,19 +4,35 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/domino14/macondo/ai/player"
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/montecarlo"
+	"github.com/domino14/macondo/strategy"
 )
 
-func (sc *ShellController) handleSim(args []string) error {
+func (sc *ShellController) handleSim(args []string, options map[string]string) error {
 	var plies, threads int
 	var err error
+	if len(args) > 0 && args[0] == "load" {
+		// Loading a sim also loads its game, so we don't need one yet.
+		return sc.simControlArguments(args)
+	}
 	if sc.simmer == nil {
 		return errors.New("load a game or something")
 	}
+	err = sc.setSimOptions(options)
+	if err != nil {
+		return err
+	}
 	// Determine whether the first argument is a string or not.
 	if len(args) > 0 {
 		plies, err = strconv.Atoi(args[0])
@@ -65,28 +81,198 @@ func (sc *ShellController) handleSim(args []string) error {
 	return nil
 }
 
+func (sc *ShellController) setSimOptions(options map[string]string) error {
+	if wp, ok := options["winpct"]; ok {
+		on, err := strconv.ParseBool(wp)
+		if err != nil {
+			return err
+		}
+		if on {
+			table, err := strategy.NewWinPCTTable(sc.game.LexiconName(), sc.config,
+				strategy.WinPCTFilename)
+			if err != nil {
+				return err
+			}
+			sc.simmer.SetWinPCTTable(table)
+		} else {
+			sc.simmer.SetWinPCTTable(nil)
+		}
+	}
+	if sortby, ok := options["sortby"]; ok {
+		switch sortby {
+		case "equity":
+			sc.simmer.SetSortMetric(montecarlo.SortByEquity)
+		case "winpct":
+			sc.simmer.SetSortMetric(montecarlo.SortByWinPCT)
+		default:
+			return errors.New("sortby must be either equity or winpct")
+		}
+	}
+	if rack, ok := options["opprack"]; ok {
+		var tiles alphabet.MachineWord
+		if rack != "none" {
+			var err error
+			tiles, err = alphabet.ToMachineWord(strings.ToUpper(rack), sc.game.Alphabet())
+			if err != nil {
+				return err
+			}
+		}
+		err := sc.simmer.SetKnownOppRack(tiles)
+		if err != nil {
+			return err
+		}
+	}
+	if stop, ok := options["stop"]; ok {
+		cond, ok := montecarlo.StoppingConditionFromString(stop)
+		if !ok {
+			return errors.New("stop must be one of 95, 98, 99, or none")
+		}
+		sc.simmer.SetStoppingCondition(cond)
+	}
+	if iters, ok := options["iterations"]; ok {
+		n, err := strconv.Atoi(iters)
+		if err != nil {
+			return err
+		}
+		sc.simmer.SetIterationLimit(n)
+	}
+	if t, ok := options["time"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return err
+		}
+		sc.simmer.SetTimeLimit(d)
+	}
+	if plies, ok := options["endgame"]; ok {
+		n, err := strconv.Atoi(plies)
+		if err != nil {
+			return err
+		}
+		sc.simmer.SetEndgamePlies(n)
+	}
+	if t, ok := options["endgametime"]; ok {
+		d, err := time.ParseDuration(t)
+		if err != nil {
+			return err
+		}
+		sc.simmer.SetEndgameTimeLimit(d)
+	}
+	if name, ok := options["endgamesolver"]; ok {
+		newSolver, err := endgameSolverFunc(name)
+		if err != nil {
+			return err
+		}
+		sc.simmer.SetEndgameSolver(newSolver)
+	}
+	err := sc.setSimPlayer(options, "our", sc.simmer.OwnPlayer(), sc.simmer.SetOwnPlayer)
+	if err != nil {
+		return err
+	}
+	err = sc.setSimPlayer(options, "opp", sc.simmer.OppPlayer(), sc.simmer.SetOppPlayer)
+	if err != nil {
+		return err
+	}
+	if seed, ok := options["seed"]; ok {
+		if seed == "none" {
+			sc.simmer.ClearSeed()
+		} else {
+			n, err := strconv.ParseInt(seed, 10, 64)
+			if err != nil {
+				return err
+			}
+			sc.simmer.SetSeed(n)
+		}
+	}
+	return nil
+}
+
+// setSimPlayer changes how one side picks its moves in the simulated plies,
+// from the -ourmodel/-ourleaves or -oppmodel/-oppleaves options. Whatever
+// is not given stays as it was.
+func (sc *ShellController) setSimPlayer(options map[string]string, side string,
+	cur player.AIPlayer, set func(player.AIPlayer)) error {
+
+	model, hasModel := options[side+"model"]
+	leaves, hasLeaves := options[side+"leaves"]
+	if !hasModel && !hasLeaves {
+		return nil
+	}
+	n := 1
+	if tp, ok := cur.(*player.TopNPlayer); ok {
+		n = tp.N()
+	}
+	if hasModel {
+		switch {
+		case model == "best":
+			n = 1
+		case strings.HasPrefix(model, "top"):
+			var err error
+			n, err = strconv.Atoi(strings.TrimPrefix(model, "top"))
+			if err != nil || n < 1 {
+				return errors.New("the top model needs a number of plays, for example top5")
+			}
+		default:
+			return errors.New("model must be either best or topN, for example top5")
+		}
+	}
+	defaultStrat := sc.game.AIPlayer().Strategizer()
+	strat := cur.Strategizer()
+	if hasLeaves {
+		if leaves == "default" {
+			strat = defaultStrat
+		} else {
+			var err error
+			strat, err = strategy.NewExhaustiveLeaveStrategy(sc.game.LexiconName(),
+				sc.game.Alphabet(), sc.config, leaves, strategy.PEGAdjustmentFilename)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	switch {
+	case n == 1 && strat == defaultStrat:
+		set(nil)
+	case n == 1:
+		set(player.NewRawEquityPlayer(strat))
+	default:
+		// The simmer gives every thread a copy with its own random source.
+		set(player.NewTopNPlayer(strat, n, rand.New(rand.NewSource(rand.Int63()))))
+	}
+	return nil
+}
+
 func (sc *ShellController) startSim() {
 	sc.simCtx, sc.simCancel = context.WithCancel(context.Background())
 	sc.simTicker = time.NewTicker(15 * time.Second)
-	sc.simTickerDone = make(chan bool)
+	sc.simTickerDone = make(chan struct{})
 	sc.showMessage("Simulation started. Please do `sim show` and `sim details` to see more info")
 
+	ctx, ticker, done := sc.simCtx, sc.simTicker, sc.simTickerDone
 	go func() {
-		err := sc.simmer.Simulate(sc.simCtx)
+		err := sc.simmer.Simulate(ctx)
+		// Only this thread stops the ticker thread, however the sim
+		// stopped.
+		close(done)
+		sc.closeSimLog()
 		if err != nil {
 			sc.showError(err)
-			sc.simTickerDone <- true
+		} else {
+			// The simmer stopped on its own; one of its stopping
+			// conditions was met.
+			sc.showMessage("Simulation stopped automatically.")
+			sc.showMessage(sc.simmer.EquityStats())
 		}
 		log.Debug().Msg("simulation thread exiting...")
 	}()
 
 	go func() {
+		defer ticker.Stop()
 		for {
 			select {
-			case <-sc.simTickerDone:
+			case <-done:
 				log.Debug().Msg("ticker thread exiting...")
 				return
-			case <-sc.simTicker.C:
+			case <-ticker.C:
 				log.Info().Msgf("Simmer is at %v iterations...",
 					sc.simmer.Iterations())
 			}
@@ -94,6 +280,20 @@ func (sc *ShellController) startSim() {
 	}()
 }
 
+// closeSimLog closes the sim log file, if there is one, whether the sim was
+// stopped or stopped on its own. Do `sim log` again to log the next sim.
+func (sc *ShellController) closeSimLog() {
+	if sc.simLogFile == nil {
+		return
+	}
+	sc.simmer.SetLogStream(nil)
+	err := sc.simLogFile.Close()
+	if err != nil {
+		sc.showError(err)
+	}
+	sc.simLogFile = nil
+}
+
 func (sc *ShellController) simControlArguments(args []string) error {
 	var err error
 	switch args[0] {
@@ -111,20 +311,22 @@ func (sc *ShellController) simControlArguments(args []string) error {
 		if !sc.simmer.IsSimming() {
 			return errors.New("no running sim to stop")
 		}
-		sc.simTicker.Stop()
-		sc.simTickerDone <- true
+		// The sim thread stops the ticker and closes the log file once
+		// the simmer returns.
 		sc.simCancel()
-		if sc.simLogFile != nil {
-			err := sc.simLogFile.Close()
-			if err != nil {
-				return err
-			}
-		}
 		// Show the results.
 		sc.showMessage(sc.simmer.EquityStats())
 	case "details":
 		sc.showMessage(sc.simmer.ScoreDetails())
 	case "show":
+		if len(args) > 1 && args[1] == "json" {
+			bts, err := protojson.MarshalOptions{Multiline: true}.Marshal(sc.simmer.Results())
+			if err != nil {
+				return err
+			}
+			sc.showMessage(string(bts))
+			return nil
+		}
 		sc.showMessage(sc.simmer.EquityStats())
 	case "continue":
 		if sc.simmer.IsSimming() {
@@ -148,9 +350,85 @@ func (sc *ShellController) simControlArguments(args []string) error {
 			return err
 		}
 		sc.showMessage(sc.simmer.EquityStats())
+	case "save":
+		if len(args) != 2 {
+			return errors.New("save needs a filename")
+		}
+		if !sc.simmer.Ready() {
+			return errors.New("there is no sim to save")
+		}
+		f, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		err = sc.simmer.WriteCheckpoint(f)
+		if err != nil {
+			return err
+		}
+		sc.showMessage("sim saved to " + args[1])
+	case "load":
+		if len(args) != 2 {
+			return errors.New("load needs a filename")
+		}
+		if sc.simmer != nil && sc.simmer.IsSimming() {
+			return errors.New("please stop the running sim first")
+		}
+		err = sc.loadSimCheckpoint(args[1])
+		if err != nil {
+			return err
+		}
+		sc.showMessage(sc.game.ToDisplayText())
+		sc.showMessage(sc.simmer.EquityStats())
+		sc.showMessage("Do `sim continue` to keep simming.")
+	case "merge":
+		if len(args) != 2 {
+			return errors.New("merge needs a filename")
+		}
+		cp, err := readSimCheckpoint(args[1])
+		if err != nil {
+			return err
+		}
+		err = sc.simmer.MergeCheckpoint(cp)
+		if err != nil {
+			return err
+		}
+		sc.showMessage(sc.simmer.EquityStats())
 	default:
 		return fmt.Errorf("do not understand sim argument %v", args[0])
 	}
 
 	return nil
 }
+
+func readSimCheckpoint(filename string) (*montecarlo.Checkpoint, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return montecarlo.ReadCheckpoint(f)
+}
+
+// loadSimCheckpoint sets up the game at the checkpoint's position, and
+// gets the simmer ready to continue where the checkpoint left off.
+func (sc *ShellController) loadSimCheckpoint(filename string) error {
+	cp, err := readSimCheckpoint(filename)
+	if err != nil {
+		return err
+	}
+	err = sc.loadHistory(cp.History)
+	if err != nil {
+		return err
+	}
+	err = sc.setToTurn(cp.Turn)
+	if err != nil {
+		return err
+	}
+	err = sc.game.SetRackFor(sc.game.PlayerOnTurn(),
+		alphabet.RackFromString(cp.Rack, sc.game.Alphabet()))
+	if err != nil {
+		return err
+	}
+	return sc.simmer.RestoreCheckpoint(cp)
+}
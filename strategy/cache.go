@@ -16,6 +16,9 @@ func LeaveCacheLoadFunc(cfg *config.Config, key string) (interface{}, error) {
 	if len(fields) != 3 {
 		return nil, errors.New("cache key missing fields")
 	}
+	if isKLVFile(fields[2]) {
+		return loadKLV(cfg, fields[2], fields[1])
+	}
 	return loadExhaustiveMPH(cfg.StrategyParamsPath, fields[2], fields[1])
 }
 
@@ -29,3 +32,14 @@ func PEGCacheLoadFunc(cfg *config.Config, key string) (interface{}, error) {
 	}
 	return loadPEGParams(cfg.StrategyParamsPath, fields[2], fields[1])
 }
+
+func WinPCTCacheLoadFunc(cfg *config.Config, key string) (interface{}, error) {
+	fields := strings.Split(key, ":")
+	if fields[0] != "winpctfile" {
+		return nil, errors.New("winpctcacheloadfunc - bad cache key: " + key)
+	}
+	if len(fields) != 3 {
+		return nil, errors.New("cache key missing fields")
+	}
+	return loadWinPCTTable(cfg.StrategyParamsPath, fields[2], fields[1])
+}
@@ -17,9 +17,11 @@ import (
 )
 
 // ExhaustiveLeaveStrategy should apply an equity calculation for all leaves
-// exhaustively.
+// exhaustively. The leave values come from a minimal perfect hash, or from
+// a KLV if the leave file is one (a .klv or .klv2 file).
 type ExhaustiveLeaveStrategy struct {
 	leaveValues                *mph.CHD
+	klv                        *KLV
 	preEndgameAdjustmentValues []float64
 }
 
@@ -60,7 +62,11 @@ func NewExhaustiveLeaveStrategy(lexiconName string,
 	if err != nil {
 		return nil, err
 	}
-	strategy.leaveValues = leaves.(*mph.CHD)
+	if klv, ok := leaves.(*KLV); ok {
+		strategy.klv = klv
+	} else {
+		strategy.leaveValues = leaves.(*mph.CHD)
+	}
 	strategy.preEndgameAdjustmentValues = pegValues.([]float64)
 
 	return strategy, nil
@@ -78,7 +84,7 @@ func (els ExhaustiveLeaveStrategy) Equity(play *move.Move, board *board.GameBoar
 	// Use global placement and endgame adjustments; this is only when
 	// not overriding this with an endgame player.
 	if board.IsEmpty() {
-		otherAdjustments += placementAdjustment(play)
+		otherAdjustments += placementAdjustment(play, board)
 	}
 
 	if bag.TilesRemaining() > 0 {
@@ -108,6 +114,9 @@ func (els ExhaustiveLeaveStrategy) LeaveValue(leave alphabet.MachineWord) float6
 	if len(leave) == 0 {
 		return 0
 	}
+	if els.klv != nil {
+		return els.klv.LeaveValue(leave)
+	}
 	if len(leave) > 1 {
 		sort.Slice(leave, func(i, j int) bool {
 			return leave[i] < leave[j]
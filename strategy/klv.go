@@ -0,0 +1,177 @@
+package strategy
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+// KLV holds the leave values of a KLV file, the leave format of other
+// engines; see gaddagmaker.GenerateKLV. The value of a leave is found by
+// its index among the words of the KLV's dawg.
+type KLV struct {
+	kwg []uint32
+	// counts has the number of words under every node of the kwg, that
+	// is, reachable from it or from its later siblings.
+	counts []uint32
+	values []float32
+	// tiles has the KWG tile of every machine letter.
+	tiles []uint8
+}
+
+// isKLVFile returns whether the leave file is a KLV, rather than a
+// minimal perfect hash.
+func isKLVFile(filename string) bool {
+	return strings.HasSuffix(filename, ".klv") || strings.HasSuffix(filename, ".klv2")
+}
+
+// ReadKLV reads a KLV from the stream. The tiles of its KWG are the letters
+// of the letter distribution, in order, with the blank as tile 0. The
+// values are 32-bit floats, or 16-bit integers in 1/256ths of a point in
+// older files.
+func ReadKLV(stream io.Reader, ld *alphabet.LetterDistribution) (*KLV, error) {
+	bts, err := ioutil.ReadAll(stream)
+	if err != nil {
+		return nil, err
+	}
+	if len(bts) < 4 {
+		return nil, errors.New("klv is too short")
+	}
+	numNodes := int(binary.LittleEndian.Uint32(bts))
+	bts = bts[4:]
+	if numNodes < 2 || len(bts) < 4*numNodes+4 {
+		return nil, errors.New("klv is too short")
+	}
+	kwg := make([]uint32, numNodes)
+	for i := range kwg {
+		kwg[i] = binary.LittleEndian.Uint32(bts[4*i:])
+		if int(kwg[i]&gaddagmaker.KWGArcIdxBitMask) >= numNodes {
+			return nil, errors.New("klv node points past the end")
+		}
+	}
+	if kwg[numNodes-1]&gaddagmaker.KWGIsEndBit == 0 {
+		return nil, errors.New("klv's last list does not end")
+	}
+	bts = bts[4*numNodes:]
+	numValues := int(binary.LittleEndian.Uint32(bts))
+	bts = bts[4:]
+	values := make([]float32, numValues)
+	switch len(bts) {
+	case 2 * numValues:
+		for i := range values {
+			values[i] = float32(int16(binary.LittleEndian.Uint16(bts[2*i:]))) / 256
+		}
+	case 4 * numValues:
+		for i := range values {
+			values[i] = math.Float32frombits(binary.LittleEndian.Uint32(bts[4*i:]))
+		}
+	default:
+		return nil, errors.New("klv values have an unknown size")
+	}
+
+	klv := &KLV{kwg: kwg, counts: make([]uint32, numNodes), values: values,
+		tiles: make([]uint8, alphabet.MaxAlphabetSize+1)}
+	if root := kwg[0] & gaddagmaker.KWGArcIdxBitMask; root != 0 {
+		// Counting the words under the root counts them for every node.
+		if int(klv.count(root)) > numValues {
+			return nil, errors.New("klv has fewer values than leaves")
+		}
+	}
+	for ml := range klv.tiles {
+		// Tiles that aren't in the distribution never match.
+		klv.tiles[ml] = math.MaxUint8
+	}
+	for i, letter := range ld.TileLetters() {
+		ml, err := ld.Alphabet().Val(letter)
+		if err != nil {
+			return nil, err
+		}
+		klv.tiles[ml] = uint8(i + 1)
+	}
+	klv.tiles[alphabet.BlankMachineLetter] = 0
+	return klv, nil
+}
+
+// count returns the number of words under the node p, counting them the
+// first time it's asked.
+func (k *KLV) count(p uint32) uint32 {
+	if k.counts[p] == 0 {
+		n := k.kwg[p]
+		c := uint32(0)
+		if n&gaddagmaker.KWGAcceptsBit != 0 {
+			c++
+		}
+		if next := n & gaddagmaker.KWGArcIdxBitMask; next != 0 {
+			c += k.count(next)
+		}
+		if n&gaddagmaker.KWGIsEndBit == 0 {
+			c += k.count(p + 1)
+		}
+		k.counts[p] = c
+	}
+	return k.counts[p]
+}
+
+// wordIndex returns the index of the word among the words of the dawg,
+// in order, or -1 if it's not there. The words before it are the ones
+// under the siblings it skips, and the shorter words on its path.
+func (k *KLV) wordIndex(word []uint8) int {
+	idx := uint32(0)
+	p := k.kwg[0] & gaddagmaker.KWGArcIdxBitMask
+	for i, tile := range word {
+		if p == 0 {
+			return -1
+		}
+		for uint8(k.kwg[p]>>gaddagmaker.KWGTileBitLoc) != tile {
+			if k.kwg[p]&gaddagmaker.KWGIsEndBit != 0 {
+				return -1
+			}
+			idx += k.counts[p] - k.counts[p+1]
+			p++
+		}
+		accepts := k.kwg[p]&gaddagmaker.KWGAcceptsBit != 0
+		if i == len(word)-1 {
+			if !accepts {
+				return -1
+			}
+			return int(idx)
+		}
+		if accepts {
+			idx++
+		}
+		p = k.kwg[p] & gaddagmaker.KWGArcIdxBitMask
+	}
+	return -1
+}
+
+// LeaveValue returns the value of the leave, or 0 if it isn't in the KLV.
+func (k *KLV) LeaveValue(leave alphabet.MachineWord) float64 {
+	var tiles [alphabet.MaxAlphabetSize]uint8
+	if len(leave) == 0 || len(leave) > len(tiles) {
+		return 0
+	}
+	word := tiles[:len(leave)]
+	for i, ml := range leave {
+		if int(ml) >= len(k.tiles) {
+			return 0
+		}
+		word[i] = k.tiles[ml]
+	}
+	// The tiles of a leave are sorted; there are only a few of them.
+	for i := 1; i < len(word); i++ {
+		for j := i; j > 0 && word[j] < word[j-1]; j-- {
+			word[j], word[j-1] = word[j-1], word[j]
+		}
+	}
+	idx := k.wordIndex(word)
+	if idx < 0 {
+		return 0
+	}
+	return float64(k.values[idx])
+}
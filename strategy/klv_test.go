@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/gaddagmaker"
+)
+
+var testLeaves = map[string]float64{
+	"?":      25.5,
+	"Q":      -7.25,
+	"?I":     26.5,
+	"AEINST": 30.75,
+	"?DLQSV": -1.25,
+	"EEEE":   -10,
+	"EEE":    -6.5,
+	"E":      1,
+	"Z":      2.5,
+}
+
+func TestKLV(t *testing.T) {
+	ld, err := alphabet.EnglishLetterDistribution(&DefaultConfig)
+	assert.Nil(t, err)
+	dir, err := ioutil.TempDir("", "klv")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	csv := ""
+	for leave, value := range testLeaves {
+		csv += leave + "," + fmt.Sprint(value) + "\n"
+	}
+	csvFile := filepath.Join(dir, "leaves.csv")
+	assert.Nil(t, ioutil.WriteFile(csvFile, []byte(csv), 0644))
+	nodes, values := gaddagmaker.GenerateKLV(csvFile, false, ld.TileLetters())
+	assert.Equal(t, len(testLeaves), len(values))
+
+	// A KLV with 32-bit values, used as the leave file of the strategy.
+	cfg := DefaultConfig
+	cfg.StrategyParamsPath = dir
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "NWL18"), 0755))
+	gaddagmaker.SaveKLV(filepath.Join(dir, "NWL18", "test.klv2"), nodes, values)
+	peg, err := ioutil.ReadFile(filepath.Join(DefaultConfig.StrategyParamsPath,
+		"default_english", PEGAdjustmentFilename))
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "NWL18", PEGAdjustmentFilename), peg, 0644))
+	els, err := NewExhaustiveLeaveStrategy("NWL18", ld.Alphabet(), &cfg, "test.klv2", "")
+	assert.Nil(t, err)
+
+	// The same KLV with 16-bit values, in 1/256ths of a point.
+	klv16File := filepath.Join(dir, "test.klv")
+	file, err := os.Create(klv16File)
+	assert.Nil(t, err)
+	binary.Write(file, binary.LittleEndian, uint32(len(nodes)))
+	binary.Write(file, binary.LittleEndian, nodes)
+	binary.Write(file, binary.LittleEndian, uint32(len(values)))
+	for _, v := range values {
+		binary.Write(file, binary.LittleEndian, int16(v*256))
+	}
+	file.Close()
+	file, err = os.Open(klv16File)
+	assert.Nil(t, err)
+	defer file.Close()
+	klv16, err := ReadKLV(file, ld)
+	assert.Nil(t, err)
+
+	type testcase struct {
+		leave string
+		ev    float64
+	}
+	cases := []testcase{
+		{"I?", 26.5},
+		{"TSNIEA", 30.75},
+		{"VSQLD?", -1.25},
+		{"EE", 0},
+		{"EEEEE", 0},
+		{"QQ", 0},
+		{"", 0},
+	}
+	for leave, value := range testLeaves {
+		cases = append(cases, testcase{leave, value})
+	}
+	for _, tc := range cases {
+		leave, err := alphabet.ToMachineWord(tc.leave, ld.Alphabet())
+		assert.Nil(t, err)
+		assert.Equal(t, tc.ev, els.LeaveValue(leave), tc.leave)
+		assert.Equal(t, tc.ev, klv16.LeaveValue(leave), tc.leave)
+	}
+}
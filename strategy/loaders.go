@@ -10,6 +10,9 @@ import (
 
 	"github.com/alecthomas/mph"
 	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/alphabet"
+	"github.com/domino14/macondo/config"
 )
 
 const (
@@ -63,6 +66,29 @@ func loadExhaustiveMPH(strategyPath, leavefile, lexiconName string) (*mph.CHD, e
 	return leaves, nil
 }
 
+// Load the leave values of a KLV, with the tiles of the lexicon's letter
+// distribution.
+func loadKLV(cfg *config.Config, leavefile, lexiconName string) (*KLV, error) {
+	ld, err := alphabet.NamedLetterDistribution(cfg,
+		alphabet.DistributionNameForLexicon(lexiconName))
+	if err != nil {
+		return nil, err
+	}
+	file, err := stratFileForLexicon(cfg.StrategyParamsPath, leavefile, lexiconName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	klv, err := ReadKLV(file, ld)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Str("lexiconName", lexiconName).
+		Int("klv-size", len(klv.values)).
+		Msg("loaded-klv")
+	return klv, nil
+}
+
 func loadPEGParams(strategyPath, filepath, lexiconName string) ([]float64, error) {
 	pegfile, err := stratFileForLexicon(strategyPath, filepath, lexiconName)
 	if err != nil {
@@ -19,7 +19,7 @@ type Strategizer interface {
 
 // Global strategy heuristics, available to all strategies.
 
-func placementAdjustment(play *move.Move) float64 {
+func placementAdjustment(play *move.Move, b *board.GameBoard) float64 {
 	// Very simply just checks how many vowels are overlapping bonus squares.
 	// This only gets considered when the board is empty.
 	if play.Action() != move.MoveTypePlay {
@@ -38,13 +38,14 @@ func placementAdjustment(play *move.Move) float64 {
 	vPenalty := -0.7 // VERY ROUGH approximation from Maven paper.
 	for j < end {
 		if play.Tiles()[j-start].IsVowel(play.Alphabet()) {
-			switch j {
-			case 2, 6, 8, 12:
-				// row/col below/above have a 2LS. note this only works
-				// for specific board configurations
+			// The row/col below/above have a letter bonus next to the
+			// vowel, for the opponent to score it twice or more.
+			r, c := row, j
+			if vertical {
+				r, c = j, col
+			}
+			if nextToLetterBonus(b, r, c, vertical) {
 				penalty += vPenalty
-			default:
-
 			}
 		}
 		j++
@@ -52,6 +53,26 @@ func placementAdjustment(play *move.Move) float64 {
 	return penalty
 }
 
+// nextToLetterBonus returns whether either square beside the given one,
+// across the direction of the play, is a letter bonus square.
+func nextToLetterBonus(b *board.GameBoard, row, col int, vertical bool) bool {
+	dr, dc := 1, 0
+	if vertical {
+		dr, dc = 0, 1
+	}
+	for _, sign := range []int{-1, 1} {
+		r, c := row+sign*dr, col+sign*dc
+		if r < 0 || c < 0 || r >= b.Dim() || c >= b.Dim() {
+			continue
+		}
+		switch b.GetBonus(r, c) {
+		case board.Bonus2LS, board.Bonus3LS, board.Bonus4LS:
+			return true
+		}
+	}
+	return false
+}
+
 func endgameAdjustment(play *move.Move, oppRack *alphabet.Rack, ld *alphabet.LetterDistribution) float64 {
 	if len(play.Leave()) != 0 {
 		// This play is not going out. We should penalize it by our own score
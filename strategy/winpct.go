@@ -0,0 +1,119 @@
+package strategy
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/domino14/macondo/cache"
+	"github.com/domino14/macondo/config"
+)
+
+const (
+	WinPCTFilename = "winpct.csv"
+)
+
+// WinPCTTable maps a spread and a number of unseen tiles to an estimated
+// probability of winning the game. The spread and the unseen tiles are
+// both from the point of view of the player whose win probability we want.
+// The default table is synthetic: it was derived from a simple normal model
+// of the remaining spread swing, not from game data, and should be replaced
+// with an empirical one.
+type WinPCTTable struct {
+	maxSpread int
+	minSpread int
+	// probs[i][t] is the win probability for a spread of maxSpread - i
+	// with t tiles unseen.
+	probs [][]float64
+}
+
+// NewWinPCTTable loads a win percentage table for the given lexicon
+// through the global cache.
+func NewWinPCTTable(lexiconName string, cfg *config.Config, filename string) (
+	*WinPCTTable, error) {
+
+	if filename == "" {
+		filename = WinPCTFilename
+	}
+	obj, err := cache.Load(cfg, "winpctfile:"+lexiconName+":"+filename, WinPCTCacheLoadFunc)
+	if err != nil {
+		return nil, err
+	}
+	return obj.(*WinPCTTable), nil
+}
+
+// The file is a CSV. Lines starting with # are comments. The first row is
+// a header: the word "spread" followed by every number of unseen tiles,
+// starting at 0. Every other row is a spread, followed by the win
+// probabilities for each number of unseen tiles. Rows must go from the
+// highest spread to the lowest in steps of one.
+func loadWinPCTTable(strategyPath, filename, lexiconName string) (*WinPCTTable, error) {
+	file, err := stratFileForLexicon(strategyPath, filename, lexiconName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readWinPCTTable(file)
+}
+
+func readWinPCTTable(r io.Reader) (*WinPCTTable, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 || !strings.EqualFold(records[0][0], "spread") {
+		return nil, errors.New("win percentage file is missing its header")
+	}
+	if len(records[0]) < 2 {
+		return nil, errors.New("win percentage file has no columns of unseen tiles")
+	}
+	table := &WinPCTTable{}
+	for idx, record := range records[1:] {
+		spread, err := strconv.Atoi(record[0])
+		if err != nil {
+			return nil, err
+		}
+		if idx == 0 {
+			table.maxSpread = spread
+		} else if spread != table.maxSpread-idx {
+			return nil, errors.New("win percentage rows must go down one spread point at a time")
+		}
+		table.minSpread = spread
+		probs := make([]float64, len(record)-1)
+		for t, val := range record[1:] {
+			probs[t], err = strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, err
+			}
+		}
+		table.probs = append(table.probs, probs)
+	}
+	log.Debug().Int("maxSpread", table.maxSpread).Int("minSpread", table.minSpread).
+		Msg("loaded-winpct-table")
+	return table, nil
+}
+
+// WinPCT returns the estimated probability of winning, from 0 to 1. Spreads
+// and tile counts outside the table are clamped to its edges.
+func (w *WinPCTTable) WinPCT(spread float64, tilesUnseen int) float64 {
+	sp := int(math.Round(spread))
+	if sp > w.maxSpread {
+		sp = w.maxSpread
+	} else if sp < w.minSpread {
+		sp = w.minSpread
+	}
+	row := w.probs[w.maxSpread-sp]
+	if tilesUnseen >= len(row) {
+		tilesUnseen = len(row) - 1
+	} else if tilesUnseen < 0 {
+		tilesUnseen = 0
+	}
+	return row[tilesUnseen]
+}
@@ -0,0 +1,48 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWinPCTTable(t *testing.T) {
+	csv := `# A comment
+spread,0,1,2
+2,1,0.9,0.8
+1,1,0.7,0.6
+0,0.5,0.5,0.5
+-1,0,0.3,0.4
+-2,0,0.1,0.2
+`
+	table, err := readWinPCTTable(strings.NewReader(csv))
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, table.WinPCT(0, 0))
+	assert.Equal(t, 0.6, table.WinPCT(1, 2))
+	assert.Equal(t, 0.3, table.WinPCT(-0.6, 1))
+	// Out-of-range values get clamped.
+	assert.Equal(t, 0.9, table.WinPCT(100, 1))
+	assert.Equal(t, 0.2, table.WinPCT(-100, 50))
+}
+
+func TestReadWinPCTTableBadRows(t *testing.T) {
+	csv := `spread,0,1
+2,1,0.9
+0,0.5,0.5
+`
+	_, err := readWinPCTTable(strings.NewReader(csv))
+	assert.NotNil(t, err)
+
+	// A table needs at least one column of probabilities.
+	_, err = readWinPCTTable(strings.NewReader("spread\n1\n0\n"))
+	assert.NotNil(t, err)
+}
+
+func TestDefaultWinPCTTable(t *testing.T) {
+	table, err := NewWinPCTTable("NWL18", &DefaultConfig, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, table.WinPCT(0, 50))
+	assert.Greater(t, table.WinPCT(40, 20), table.WinPCT(40, 80))
+	assert.Equal(t, 1.0, table.WinPCT(1, 0))
+}